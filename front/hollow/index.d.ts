@@ -1,5 +1,6 @@
 export interface Content {
     name: string
+    file: string // source file path, e.g. contents/hello.md
     getContent: (getContentOpt?: getContentOpt) => string
     meta?: Record<string, any>
     ext?: string // file extension
@@ -7,6 +8,18 @@ export interface Content {
     is_dir: boolean
     children?: Content[]
     toc?: TocItems[]
+    lang?: string // language of the content, empty if `languages` is not configured
+    translations?: ContentTranslation[] // other language variants
+    created?: string // first commit date, only if `git_dates` is enabled, can be parsed by `new Date()`
+    updated?: string // last commit date, only if `git_dates` is enabled
+    word_count?: number // CJK characters count as one word each
+    reading_time?: number // estimated reading time in minutes
+    excerpt?: string // plain text before `<!--more-->`, or the first 200 characters
+}
+
+export interface ContentTranslation {
+    lang: string
+    path: string // source file path, can be passed to getContentDetail
 }
 
 export interface TocItems {
@@ -24,21 +37,110 @@ type Config = Record<string, any>
 interface GetArticlesOptions {
     sort?: (a: Content, b: Content) => boolean
     filter?: (a: Content) => boolean
-    page?: number
-    size?: number
+    page?: number // start from 1
+    size?: number // page size, no pagination if 0
     tree?: boolean // return article tree if true
+    lang?: string // only return contents of this language
+}
+
+interface GetContentDetailOptions {
+    lang?: string // return the variant of this language if exists
+}
+
+export interface Language {
+    code: string
+    name: string
+}
+
+export interface Languages {
+    default: string
+    list: Language[]
+    dir: string
 }
 
 export interface ArticleList {
-    total: number
+    total: number // total count before pagination
     list: Content[]
 }
 
+interface PaginateOptions {
+    size?: number // default 10
+    path: string // path of the first page, others are `${path}/page/${n}`
+}
+
+export interface Pagination<T> {
+    page: number // start from 1
+    total_pages: number
+    total: number
+    list: T[]
+    path: string
+    prev: string // path of the previous page, empty if not exist
+    next: string // path of the next page, empty if not exist
+}
+
 export function getContents(path: string, option?: GetArticlesOptions): ArticleList;
 
 export function getConfig(): Config;
 
-export function getContentDetail(path: string): Content;
+// parsed yaml / json / toml / csv file in the `data` directory, the extension can be omitted, e.g. getData('authors')
+// a directory returns an object keyed by file name, returns null if not found
+export function getData<T = any>(path: string): T;
+
+// url of the code highlight stylesheet, e.g. /highlight.css, empty if `highlight` is not enabled or uses inline styles
+export function getHighlightCssUrl(): string;
+
+export interface ImageOptions {
+    width?: number;
+    height?: number;
+    // how to resize when both width and height are set, default 'cover'
+    fit?: 'cover' | 'contain' | 'fill';
+    // default to the format of the source image, webp requires cwebp
+    format?: 'jpeg' | 'jpg' | 'png' | 'webp';
+    quality?: number;
+}
+
+export interface ImageResult {
+    url: string;
+    width: number;
+    height: number;
+}
+
+// resize or crop a jpeg / png image in the site or the theme, e.g. image('contents/cover.jpg', {width: 800})
+// the image is generated at build time, returns null if not found
+export function image(path: string, options?: ImageOptions): ImageResult | null;
+
+// url of the prebuilt search index, e.g. /search/index.json, empty if `search` is not enabled
+export function getSearchIndexUrl(): string;
+
+export function getContentDetail(path: string, option?: GetContentDetailOptions): Content;
+
+export function getLanguages(): Languages;
+
+// translate key with string tables in `i18n/${lang}.yml`, e.g. t('nav.home', 'en')
+export function t(key: string, lang?: string): string;
+
+export interface TaxonomyTerm {
+    name: string
+    count: number
+    list: Content[] // sorted by meta.date desc
+}
+
+interface TaxonomyOptions {
+    lang?: string // only count contents of this language
+}
+
+// terms of the taxonomy (e.g. 'tags') sorted by count desc
+export function getTaxonomy(name: string, option?: TaxonomyOptions): TaxonomyTerm[];
+
+// contents of the term, e.g. getTerms('tags', 'golang')
+export function getTerms(name: string, term: string, option?: TaxonomyOptions): Content[];
+
+// e.g. paginate(getContents('contents').list, {size: 10, path: 'posts'}).map(p => ({path: p.path, component: () => <List {...p}/>}))
+export function paginate<T>(list: T[], option: PaginateOptions): Pagination<T>[];
+
+// custom content loader exported by the theme, returns html content
+// e.g. export default {pages, assets, loaders: {'.org': (source, path) => ({meta: {title: 'hello'}, content: '<p>hello</p>'})}}
+export type ContentLoader = (source: string, path: string) => { meta?: Record<string, any>, content: string }
 
 interface MdOption {
     unwrap: boolean
@@ -10,6 +10,7 @@ import (
 	"github.com/zbysir/hollow/internal/pkg/config"
 	"github.com/zbysir/hollow/internal/pkg/log"
 	"github.com/zbysir/hollow/internal/pkg/signal"
+	"time"
 )
 
 type ApiParams struct {
@@ -17,6 +18,10 @@ type ApiParams struct {
 	Source        string `json:"source"`
 	PreviewDomain string `json:"preview_domain"`
 	Secret        string `json:"secret"`
+
+	Schedule         bool   `json:"schedule"`
+	ScheduleDir      string `json:"schedule_dir"`
+	ScheduleInterval int    `json:"schedule_interval"`
 }
 
 func Api() *cobra.Command {
@@ -45,6 +50,10 @@ func Api() *cobra.Command {
 			}, api.Config{
 				PreviewDomain: p.PreviewDomain,
 				Secret:        p.Secret,
+
+				Schedule:         p.Schedule,
+				ScheduleDir:      p.ScheduleDir,
+				ScheduleInterval: time.Duration(p.ScheduleInterval) * time.Second,
 			})
 
 			ctx, c := signal.NewContext()
@@ -61,6 +70,9 @@ func Api() *cobra.Command {
 	config.DeclareFlag(v, cmd, "source", "s", ".", "source file dir")
 	config.DeclareFlag(v, cmd, "preview_domain", "p", "", "preview website with the domain ")
 	config.DeclareFlag(v, cmd, "secret", "c", "", "secret for web ui")
+	config.DeclareFlag(v, cmd, "schedule", "", false, "publish automatically when publish_at of a content is reached")
+	config.DeclareFlag(v, cmd, "schedule_dir", "", "contents", "content dir to find scheduled contents")
+	config.DeclareFlag(v, cmd, "schedule_interval", "", 60, "seconds between scans for new scheduled contents")
 
 	return cmd
 }
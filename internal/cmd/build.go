@@ -7,11 +7,20 @@ import (
 	"github.com/zbysir/hollow/internal/hollow"
 	"github.com/zbysir/hollow/internal/pkg/config"
 	"github.com/zbysir/hollow/internal/pkg/log"
+	"runtime"
 )
 
 type BuildParams struct {
-	Output string `json:"output"`
-	Source string `json:"source"`
+	Output      string `json:"output"`
+	Source      string `json:"source"`
+	Incremental bool   `json:"incremental"`
+	Workers     int    `json:"workers"`
+
+	ContinueOnError bool   `json:"continue_on_error"`
+	Strict          bool   `json:"strict"`
+	Report          string `json:"report"`
+	Drafts          bool   `json:"drafts"`
+	RefreshTheme    bool   `json:"refresh_theme"`
 }
 
 func Build() *cobra.Command {
@@ -39,7 +48,17 @@ func Build() *cobra.Command {
 				return err
 			}
 
-			err = ho.Build(hollow.NewRenderContext(), p.Output, hollow.ExecOption{IsDev: true})
+			err = ho.Build(hollow.NewRenderContext(), p.Output, hollow.ExecOption{
+				IsDev:       true,
+				Incremental: p.Incremental,
+				Workers:     p.Workers,
+
+				ContinueOnError: p.ContinueOnError,
+				Strict:          p.Strict,
+				ReportFile:      p.Report,
+				ShowDrafts:      p.Drafts,
+				RefreshTheme:    p.RefreshTheme,
+			})
 			if err != nil {
 				return err
 			}
@@ -50,5 +69,12 @@ func Build() *cobra.Command {
 
 	config.DeclareFlag(v, cmd, "output", "o", "./dist", "output dir")
 	config.DeclareFlag(v, cmd, "source", "s", ".", "source file dir")
+	config.DeclareFlag(v, cmd, "incremental", "i", false, "only render and write pages whose sources changed since last build")
+	config.DeclareFlag(v, cmd, "workers", "w", runtime.NumCPU(), "number of workers to render pages in parallel")
+	config.DeclareFlag(v, cmd, "continue_on_error", "", false, "render all pages even if some fail, and exit with error at the end")
+	config.DeclareFlag(v, cmd, "strict", "", false, "fail the build if any content fails to load")
+	config.DeclareFlag(v, cmd, "report", "r", "", "write a json report of all build errors to the file")
+	config.DeclareFlag(v, cmd, "drafts", "", false, "include drafts, scheduled and expired contents, e.g. for a preview site")
+	config.DeclareFlag(v, cmd, "refresh_theme", "", false, "download the remote theme again instead of using the cached one")
 	return cmd
 }
@@ -18,6 +18,8 @@ type ServerParams struct {
 	Source  string `json:"source"`
 	Theme   string `json:"theme"`
 	Cache   string `json:"cache"`
+	Watch   bool   `json:"watch"`
+	Drafts  bool   `json:"drafts"`
 }
 
 func Server() *cobra.Command {
@@ -63,8 +65,10 @@ func Server() *cobra.Command {
 				addr := p.Address
 				log.Infof("listening %v", addr)
 				err = h.Service(ctx, hollow.ExecOption{
-					Log:   nil,
-					IsDev: true,
+					Log:        nil,
+					IsDev:      true,
+					LiveReload: p.Watch,
+					ShowDrafts: p.Drafts,
 				}, addr)
 
 				if err != nil {
@@ -72,6 +76,18 @@ func Server() *cobra.Command {
 				}
 			}()
 
+			if p.Watch {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+
+					err := h.Watch(ctx)
+					if err != nil {
+						log.Errorf("watch error: %v", err)
+					}
+				}()
+			}
+
 			wg.Add(1)
 			go func() {
 				defer wg.Done()
@@ -91,5 +107,7 @@ func Server() *cobra.Command {
 	config.DeclareFlag(v, cmd, "source", "s", ".", "source file dir")
 	config.DeclareFlag(v, cmd, "theme", "t", "", "specify theme")
 	config.DeclareFlag(v, cmd, "cache", "c", "memory", "cache file path, default in memory")
+	config.DeclareFlag(v, cmd, "watch", "", true, "reload the opened pages when source or theme files change")
+	config.DeclareFlag(v, cmd, "drafts", "", true, "show drafts, scheduled and expired contents")
 	return cmd
 }
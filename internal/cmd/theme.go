@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"github.com/go-git/go-billy/v5/osfs"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/zbysir/hollow/internal/hollow"
+	"github.com/zbysir/hollow/internal/pkg/config"
+	"github.com/zbysir/hollow/internal/pkg/log"
+)
+
+type ThemeUpdateParams struct {
+	Source string `json:"source"`
+	Theme  string `json:"theme"`
+}
+
+func Theme() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "theme",
+		Short: "manage the theme of your website",
+	}
+	cmd.AddCommand(themeUpdate())
+	return cmd
+}
+
+func themeUpdate() *cobra.Command {
+	v := viper.New()
+	v.AutomaticEnv()
+
+	cmd := &cobra.Command{
+		Use:   "update",
+		Short: "update the git theme to the latest commit of its branch or tag, and rewrite hollow.lock",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			p, err := config.Get[ThemeUpdateParams](v)
+			if err != nil {
+				return err
+			}
+
+			ho, err := hollow.NewHollow(hollow.Option{
+				SourceFs:   osfs.New(p.Source),
+				FixedTheme: p.Theme,
+			})
+			if err != nil {
+				return err
+			}
+
+			lock, err := ho.UpdateTheme(hollow.NewRenderContext())
+			if err != nil {
+				return err
+			}
+			log.Infof("theme %v locked at %v", lock.Url, lock.Commit)
+			return nil
+		},
+	}
+
+	config.DeclareFlag(v, cmd, "source", "s", ".", "source file dir")
+	config.DeclareFlag(v, cmd, "theme", "t", "", "specify theme")
+	return cmd
+}
@@ -26,6 +26,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -39,11 +40,17 @@ type Api struct {
 	hub              *ws.WsHub
 	projectFsFactory FsFactory
 	config           Config
+	scheduler        *Scheduler // 没有开启定时发布时为 nil
+	publishLock      sync.Mutex // 手动发布与定时发布不能同时进行
 }
 
 type Config struct {
 	PreviewDomain string // 只要当访问域名能匹配上时，才会渲染，否则显示编辑器
 	Secret        string
+
+	Schedule         bool          // 开启定时发布，到达 publish_at 时自动发布
+	ScheduleDir      string        // 定时发布读取的内容文件夹，默认为 contents
+	ScheduleInterval time.Duration // 重新读取定时内容的间隔，默认为 1 分钟
 }
 
 type FsFactory func(pid int64) (billy.Filesystem, error)
@@ -53,11 +60,15 @@ func NewEditor(
 	config Config,
 ) *Api {
 	hub := ws.NewHub()
-	return &Api{
+	a := &Api{
 		hub:              hub,
 		projectFsFactory: projectFsFactory,
 		config:           config,
 	}
+	if config.Schedule {
+		a.scheduler = NewScheduler(hub, projectFsFactory, config.ScheduleDir, config.ScheduleInterval, &a.publishLock)
+	}
+	return a
 }
 
 type fileTreeParams struct {
@@ -190,6 +201,10 @@ func (a *Api) Run(ctx context.Context, addr string) (err error) {
 	r := gin.Default()
 	r.Use(Cors())
 
+	if a.scheduler != nil {
+		go a.scheduler.Run(ctx)
+	}
+
 	var handleRender = func(c *gin.Context) {
 		fsSource, err := a.projectFsFactory(0)
 		if err != nil {
@@ -201,8 +216,9 @@ func (a *Api) Run(ctx context.Context, addr string) (err error) {
 		})
 
 		b.ServiceHandle(hollow.ExecOption{
-			Log:   nil,
-			IsDev: true,
+			Log:        nil,
+			IsDev:      true,
+			ShowDrafts: true,
 		})(c.Writer, c.Request)
 		//c.Abort()
 	}
@@ -296,6 +312,50 @@ func (a *Api) Run(ctx context.Context, addr string) (err error) {
 		c.JSON(200, conf)
 	})
 
+	// 未到发布时间的内容
+	apiAuth.GET("/scheduled", func(c *gin.Context) {
+		var p struct {
+			Dir string `form:"dir"`
+		}
+		err = c.BindQuery(&p)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		if p.Dir == "" {
+			p.Dir = "contents"
+		}
+
+		fsSource, err := a.projectFs(0, "project")
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		b, err := hollow.NewHollow(hollow.Option{
+			SourceFs: fsSource,
+		})
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		list, err := b.ScheduledContents(hollow.NewRenderContext(), p.Dir, time.Now())
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.JSON(200, list)
+	})
+
+	// 定时发布的状态：等待发布的内容与最近的发布记录，发布日志可以通过 /ws/{key} 获取
+	apiAuth.GET("/schedule", func(c *gin.Context) {
+		if a.scheduler == nil {
+			c.AbortWithError(400, fmt.Errorf("schedule is disabled"))
+			return
+		}
+		c.JSON(200, a.scheduler.Status())
+	})
+
 	apiAuth.GET("/file/tree", func(c *gin.Context) {
 		var p fileTreeParams
 		err = c.BindQuery(&p)
@@ -533,6 +593,8 @@ func (a *Api) Run(ctx context.Context, addr string) (err error) {
 
 			logWs := NewWsLog(a.hub, key)
 			hollowLog := logWs.Named("[Hollow]")
+			a.publishLock.Lock()
+			defer a.publishLock.Unlock()
 			hollowLog.Infof("start publish")
 
 			dst := memfs.New()
@@ -0,0 +1,232 @@
+package api
+
+import (
+	"context"
+	"github.com/go-git/go-billy/v5"
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/thoas/go-funk"
+	"github.com/zbysir/hollow/internal/hollow"
+	"github.com/zbysir/hollow/internal/pkg/log"
+	ws "github.com/zbysir/hollow/internal/pkg/ws"
+	"go.uber.org/zap"
+	"sync"
+	"time"
+)
+
+// scheduleHistorySize 保留的定时发布记录数量，更早的记录与日志会被清除
+const scheduleHistorySize = 20
+
+// scheduleRetryDelay 发布失败后第一次重试的等待时间，之后每次失败翻倍，最多等待 interval
+const scheduleRetryDelay = 10 * time.Second
+
+// publishProject 构建并发布项目，与手动发布相同
+func publishProject(fs billy.Filesystem, l *zap.SugaredLogger) error {
+	b, err := hollow.NewHollow(hollow.Option{
+		SourceFs: fs,
+	})
+	if err != nil {
+		return err
+	}
+
+	return b.BuildAndPublish(hollow.NewRenderContext(), memfs.New(), hollow.ExecOption{
+		Log: l,
+	})
+}
+
+// ScheduleRun 是一次定时发布，日志可以通过 /ws/{key} 获取
+type ScheduleRun struct {
+	Key      string                    `json:"key"`
+	Contents []hollow.ScheduledContent `json:"contents"` // 到达发布时间的内容
+	Start    time.Time                 `json:"start"`
+	End      time.Time                 `json:"end"`
+	Error    string                    `json:"error"`
+}
+
+type ScheduleStatus struct {
+	Scheduled []hollow.ScheduledContent `json:"scheduled"` // 等待发布的内容
+	Runs      []ScheduleRun             `json:"runs"`      // 最近的发布记录，新的在前
+}
+
+// Scheduler 定时发布，读取项目中内容的 publish_at（或 publish_date），到达发布时间时自动构建并发布，
+// 新增的定时内容会在每隔 interval 重新读取时被发现。
+// 发布时间在上一次发布（或者启动）之后且已经到达的内容都会被发布，即使它在两次读取之间才被添加；
+// 在 api 没有运行期间到达发布时间的内容不会被自动发布。
+// 发布失败时不会更新 since，等待一段时间后重试。
+type Scheduler struct {
+	hub       *ws.WsHub
+	fsFactory FsFactory
+	dir       string
+	interval  time.Duration
+	// retryDelay 发布失败后第一次重试的等待时间
+	retryDelay time.Duration
+	publish    func(fs billy.Filesystem, l *zap.SugaredLogger) error
+	// publishLock 与手动发布共用，同一时间只有一个发布
+	publishLock sync.Locker
+
+	l      sync.Mutex
+	status ScheduleStatus
+	since  time.Time // 发布时间在 since 之前的内容已经发布过
+}
+
+// NewScheduler 创建定时发布，publishLock 为 nil 时不与其他发布互斥
+func NewScheduler(hub *ws.WsHub, fsFactory FsFactory, dir string, interval time.Duration, publishLock sync.Locker) *Scheduler {
+	if dir == "" {
+		dir = "contents"
+	}
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	if publishLock == nil {
+		publishLock = &sync.Mutex{}
+	}
+	return &Scheduler{
+		hub:         hub,
+		fsFactory:   fsFactory,
+		dir:         dir,
+		interval:    interval,
+		retryDelay:  scheduleRetryDelay,
+		publish:     publishProject,
+		publishLock: publishLock,
+		since:       time.Now(),
+	}
+}
+
+// scan 返回发布时间在 since 之后的内容
+func (s *Scheduler) scan(since time.Time) ([]hollow.ScheduledContent, error) {
+	fs, err := s.fsFactory(0)
+	if err != nil {
+		return nil, err
+	}
+	b, err := hollow.NewHollow(hollow.Option{
+		SourceFs: fs,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return b.ScheduledContents(hollow.NewRenderContext(), s.dir, since)
+}
+
+// Run 阻塞运行直到 ctx 结束
+func (s *Scheduler) Run(ctx context.Context) {
+	// failures 连续失败的次数
+	failures := 0
+	for {
+		now := time.Now()
+		list, err := s.scan(s.since)
+		if err != nil {
+			log.Warnf("scan scheduled contents error: %v", err)
+		}
+
+		var due, scheduled []hollow.ScheduledContent
+		for _, c := range list {
+			if c.PublishDate.After(now) {
+				scheduled = append(scheduled, c)
+			} else {
+				due = append(due, c)
+			}
+		}
+		s.l.Lock()
+		s.status.Scheduled = scheduled
+		s.l.Unlock()
+
+		wait := s.interval
+		if len(due) != 0 {
+			if err := s.run(due); err == nil {
+				failures = 0
+				s.since = now
+				continue
+			}
+			wait = s.retryWait(failures)
+			failures++
+		}
+
+		if len(scheduled) != 0 {
+			if d := time.Until(scheduled[0].PublishDate); d < wait {
+				wait = d
+			}
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// retryWait 返回连续失败 failures 次之后下一次重试前的等待时间
+func (s *Scheduler) retryWait(failures int) time.Duration {
+	wait := s.retryDelay
+	for i := 0; i < failures && wait < s.interval; i++ {
+		wait *= 2
+	}
+	if wait > s.interval {
+		wait = s.interval
+	}
+	return wait
+}
+
+// run 发布到达发布时间的内容，返回发布的错误
+func (s *Scheduler) run(due []hollow.ScheduledContent) error {
+	r := ScheduleRun{
+		Key:      "schedule-" + funk.RandomString(6),
+		Contents: due,
+		Start:    time.Now(),
+	}
+	s.addRun(r)
+
+	logWs := NewWsLog(s.hub, r.Key)
+	hollowLog := logWs.Named("[Schedule]")
+	for _, c := range due {
+		hollowLog.Infof("publish '%v' scheduled at %v", c.File, c.PublishDate.Format(time.RFC3339))
+	}
+
+	s.publishLock.Lock()
+	fs, err := s.fsFactory(0)
+	if err == nil {
+		err = s.publish(fs, logWs)
+	}
+	s.publishLock.Unlock()
+	r.End = time.Now()
+	if err != nil {
+		r.Error = err.Error()
+		hollowLog.Errorf("publish fail: %v", err)
+		log.Errorf("scheduled publish fail: %v", err)
+	} else {
+		hollowLog.Infof("publish success in %s", r.End.Sub(r.Start))
+	}
+	s.addRun(r)
+	return err
+}
+
+// addRun 添加或者更新发布记录
+func (s *Scheduler) addRun(r ScheduleRun) {
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	for i, o := range s.status.Runs {
+		if o.Key == r.Key {
+			s.status.Runs[i] = r
+			return
+		}
+	}
+	s.status.Runs = append([]ScheduleRun{r}, s.status.Runs...)
+	if len(s.status.Runs) > scheduleHistorySize {
+		for _, o := range s.status.Runs[scheduleHistorySize:] {
+			s.hub.Close(o.Key)
+		}
+		s.status.Runs = s.status.Runs[:scheduleHistorySize]
+	}
+}
+
+func (s *Scheduler) Status() ScheduleStatus {
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	return ScheduleStatus{
+		Scheduled: append([]hollow.ScheduledContent{}, s.status.Scheduled...),
+		Runs:      append([]ScheduleRun{}, s.status.Runs...),
+	}
+}
@@ -0,0 +1,181 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"github.com/go-git/go-billy/v5"
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/go-git/go-billy/v5/util"
+	"github.com/stretchr/testify/assert"
+	ws "github.com/zbysir/hollow/internal/pkg/ws"
+	"go.uber.org/zap"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestScheduler(t *testing.T) {
+	source := memfs.New()
+	publishAt := time.Now().Add(2 * time.Second).UTC().Truncate(time.Second)
+	err := util.WriteFile(source, "contents/a.md", []byte("---\ntitle: A\npublish_at: "+publishAt.Format(time.RFC3339)+"\n---\na"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = util.WriteFile(source, "contents/b.md", []byte("---\ntitle: B\n---\nb"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := NewScheduler(ws.NewHub(), func(pid int64) (billy.Filesystem, error) {
+		return source, nil
+	}, "", time.Hour, nil)
+	published := make(chan time.Time, 1)
+	s.publish = func(fs billy.Filesystem, l *zap.SugaredLogger) error {
+		published <- time.Now()
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.Run(ctx)
+
+	select {
+	case at := <-published:
+		assert.False(t, at.Before(publishAt))
+	case <-time.After(10 * time.Second):
+		t.Fatal("scheduled content is not published")
+	}
+
+	// 发布完成后记录状态，a.md 不再是定时内容
+	var status ScheduleStatus
+	for i := 0; i < 50; i++ {
+		status = s.Status()
+		if len(status.Runs) == 1 && !status.Runs[0].End.IsZero() && len(status.Scheduled) == 0 {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	if assert.Len(t, status.Runs, 1) {
+		assert.Equal(t, "", status.Runs[0].Error)
+		if assert.Len(t, status.Runs[0].Contents, 1) {
+			assert.Equal(t, "contents/a.md", status.Runs[0].Contents[0].File)
+		}
+	}
+	assert.Len(t, status.Scheduled, 0)
+}
+
+func TestSchedulerMissedScan(t *testing.T) {
+	source := memfs.New()
+	now := time.Now().UTC().Truncate(time.Second)
+	// a.md 在上一次发布之后、这次读取之前到达发布时间，b.md 在上一次发布之前已经发布过
+	err := util.WriteFile(source, "contents/a.md", []byte("---\ntitle: A\npublish_at: "+now.Add(-30*time.Second).Format(time.RFC3339)+"\n---\na"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = util.WriteFile(source, "contents/b.md", []byte("---\ntitle: B\npublish_at: "+now.Add(-2*time.Minute).Format(time.RFC3339)+"\n---\nb"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 与手动发布共用锁，手动发布结束后才会发布
+	var lock sync.Mutex
+	s := NewScheduler(ws.NewHub(), func(pid int64) (billy.Filesystem, error) {
+		return source, nil
+	}, "", time.Hour, &lock)
+	s.since = now.Add(-time.Minute)
+	published := make(chan struct{}, 1)
+	s.publish = func(fs billy.Filesystem, l *zap.SugaredLogger) error {
+		published <- struct{}{}
+		return nil
+	}
+
+	lock.Lock()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.Run(ctx)
+
+	select {
+	case <-published:
+		t.Fatal("published while another publish is running")
+	case <-time.After(500 * time.Millisecond):
+	}
+	lock.Unlock()
+
+	select {
+	case <-published:
+	case <-time.After(10 * time.Second):
+		t.Fatal("due content is not published")
+	}
+
+	var status ScheduleStatus
+	for i := 0; i < 50; i++ {
+		status = s.Status()
+		if len(status.Runs) == 1 && !status.Runs[0].End.IsZero() {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	if assert.Len(t, status.Runs, 1) && assert.Len(t, status.Runs[0].Contents, 1) {
+		assert.Equal(t, "contents/a.md", status.Runs[0].Contents[0].File)
+	}
+}
+
+func TestSchedulerRetry(t *testing.T) {
+	source := memfs.New()
+	now := time.Now().UTC().Truncate(time.Second)
+	err := util.WriteFile(source, "contents/a.md", []byte("---\ntitle: A\npublish_at: "+now.Add(-30*time.Second).Format(time.RFC3339)+"\n---\na"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := NewScheduler(ws.NewHub(), func(pid int64) (billy.Filesystem, error) {
+		return source, nil
+	}, "", time.Hour, nil)
+	s.since = now.Add(-time.Minute)
+	s.retryDelay = 100 * time.Millisecond
+	// 第一次发布失败
+	published := make(chan struct{}, 2)
+	failed := false
+	s.publish = func(fs billy.Filesystem, l *zap.SugaredLogger) error {
+		published <- struct{}{}
+		if !failed {
+			failed = true
+			return errors.New("network error")
+		}
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.Run(ctx)
+
+	for i := 0; i < 2; i++ {
+		select {
+		case <-published:
+		case <-time.After(10 * time.Second):
+			t.Fatal("failed publish is not retried")
+		}
+	}
+
+	var status ScheduleStatus
+	for i := 0; i < 50; i++ {
+		status = s.Status()
+		if len(status.Runs) == 2 && !status.Runs[0].End.IsZero() {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	// 重试时依然发布 a.md，成功之后不会再次发布
+	if assert.Len(t, status.Runs, 2) {
+		assert.Equal(t, "", status.Runs[0].Error)
+		assert.Equal(t, "network error", status.Runs[1].Error)
+		if assert.Len(t, status.Runs[0].Contents, 1) {
+			assert.Equal(t, "contents/a.md", status.Runs[0].Contents[0].File)
+		}
+	}
+	select {
+	case <-published:
+		t.Fatal("published again after success")
+	case <-time.After(500 * time.Millisecond):
+	}
+}
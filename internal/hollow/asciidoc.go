@@ -0,0 +1,387 @@
+package hollow
+
+import (
+	jsx "github.com/zbysir/gojsx"
+	"io/fs"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// AsciiDocLoader 加载 .adoc 文件，支持 AsciiDoc 的常用子集：
+//   - 文档标题 = Title 与紧随其后的 :key: value 属性（作为 meta），也支持 --- 格式的 meta
+//   - 章节标题 == ~ ======，可以使用 [[id]] 或 [#id] 指定 id
+//   - 段落、* / - / . 列表（使用重复的标记表示嵌套）、NOTE: 等提示段落
+//   - ---- 代码块（[source,go] 指定语言）、.... 原样输出块、____ 引用块、”' 分割线
+//   - image::path[alt] 图片、// 与 //// 注释
+//   - 行内 *粗体* _斜体_ `代码`、链接 https://url[text] link:url[text]、行内图片 image:path[alt]
+type AsciiDocLoader struct {
+	fs        fs.FS
+	assets    Assets
+	images    *imagePipeline
+	diagrams  *diagramRenderer
+	highlight *codeHighlighter
+}
+
+func NewAsciiDocLoader(fs fs.FS, assets Assets) *AsciiDocLoader {
+	return &AsciiDocLoader{fs: fs, assets: assets}
+}
+
+func (l *AsciiDocLoader) Load(filePath string, withContent bool) (Content, error) {
+	meta, body, err := readFrontMatterFile(l.fs, filePath)
+	if err != nil {
+		return Content{}, err
+	}
+
+	p := adocParser{lines: strings.Split(strings.ReplaceAll(string(body), "\r\n", "\n"), "\n")}
+	p.parseHeader(meta)
+	children := p.parse()
+	dom := jsx.VDom{"nodeName": "", "attributes": map[string]interface{}{"children": children}}
+
+	fileDir := filepath.Dir(filePath)
+	if !strings.HasPrefix(fileDir, "/") {
+		fileDir = "/" + fileDir
+	}
+	assets := replaceImgUrl(l.assets, dom, fileDir)
+	l.images.rewrite(dom)
+	l.diagrams.rewrite(dom)
+	l.highlight.rewrite(dom)
+
+	return newContent(filePath, meta, dom, generateTOC(dom).Items, assets), nil
+}
+
+var (
+	adocAttrRe      = regexp.MustCompile(`^:([\w-]+):\s*(.*)$`)
+	adocHeadingRe   = regexp.MustCompile(`^(={2,6})\s+(.+)$`)
+	adocAnchorRe    = regexp.MustCompile(`^(?:\[\[([^\]]+)\]\]|\[#([^\]]+)\])$`)
+	adocBlockAttrRe = regexp.MustCompile(`^\[([^\]]*)\]$`)
+	adocListRe      = regexp.MustCompile(`^(\*{1,5}|-|\.{1,5})\s+(.+)$`)
+	adocImageRe     = regexp.MustCompile(`^image::([^\[\s]+)\[([^\]]*)\]$`)
+	adocAdmonRe     = regexp.MustCompile(`^(NOTE|TIP|IMPORTANT|WARNING|CAUTION):\s+(.+)$`)
+	adocInlineRe    = regexp.MustCompile("`([^`]+)`" +
+		`|image:([^\[\s:][^\[\s]*)\[([^\]]*)\]` +
+		`|link:([^\[\s]+)\[([^\]]*)\]` +
+		`|(https?://[^\s\[]+)(?:\[([^\]]*)\])?` +
+		`|\*([^*\s](?:[^*]*[^*\s])?)\*` +
+		`|_([^_\s](?:[^_]*[^_\s])?)_`)
+)
+
+type adocParser struct {
+	lines []string
+	i     int
+}
+
+func adocEl(name string, attr map[string]interface{}, children ...interface{}) jsx.VDom {
+	if attr == nil {
+		attr = map[string]interface{}{}
+	}
+	if len(children) != 0 {
+		attr["children"] = children
+	}
+	return jsx.VDom{"nodeName": name, "attributes": attr}
+}
+
+func (p *adocParser) next() (string, bool) {
+	if p.i >= len(p.lines) {
+		return "", false
+	}
+	l := p.lines[p.i]
+	p.i++
+	return l, true
+}
+
+func (p *adocParser) peek() (string, bool) {
+	if p.i >= len(p.lines) {
+		return "", false
+	}
+	return p.lines[p.i], true
+}
+
+// parseHeader 解析文档标题与属性，meta 中已有的字段不会被覆盖
+func (p *adocParser) parseHeader(meta map[string]interface{}) {
+	for {
+		l, ok := p.peek()
+		if !ok || strings.TrimSpace(l) != "" {
+			break
+		}
+		p.i++
+	}
+	l, ok := p.peek()
+	if !ok || !strings.HasPrefix(l, "= ") {
+		return
+	}
+	p.i++
+	if _, ok := meta["title"]; !ok {
+		meta["title"] = strings.TrimSpace(strings.TrimPrefix(l, "= "))
+	}
+	for {
+		l, ok := p.peek()
+		if !ok {
+			return
+		}
+		m := adocAttrRe.FindStringSubmatch(l)
+		if m == nil {
+			return
+		}
+		p.i++
+		if _, ok := meta[m[1]]; !ok {
+			meta[m[1]] = m[2]
+		}
+	}
+}
+
+// readDelimited 读取到 delimiter 行为止的内容
+func (p *adocParser) readDelimited(delimiter string) []string {
+	var ls []string
+	for {
+		l, ok := p.next()
+		if !ok || l == delimiter {
+			return ls
+		}
+		ls = append(ls, l)
+	}
+}
+
+func (p *adocParser) parse() []interface{} {
+	var nodes []interface{}
+	var id string        // [[id]] 指定的 id，作用于下一个标题
+	var blockAttr string // [source,go] 等属性，作用于下一个块
+	var paragraph []string
+	var list *adocList
+
+	flush := func() {
+		if len(paragraph) != 0 {
+			text := strings.Join(paragraph, " ")
+			if m := adocAdmonRe.FindStringSubmatch(text); m != nil {
+				kind := strings.ToLower(m[1])
+				nodes = append(nodes, adocEl("div", map[string]interface{}{"class": "admonition " + kind}, adocEl("p", nil, adocInline(m[2])...)))
+			} else {
+				nodes = append(nodes, adocEl("p", nil, adocInline(text)...))
+			}
+			paragraph = nil
+		}
+		if list != nil {
+			nodes = append(nodes, list.root())
+			list = nil
+		}
+	}
+
+	for {
+		l, ok := p.next()
+		if !ok {
+			break
+		}
+		trim := strings.TrimSpace(l)
+
+		switch {
+		case trim == "":
+			flush()
+			continue
+		case trim == "////":
+			flush()
+			p.readDelimited("////")
+			continue
+		case strings.HasPrefix(trim, "//"):
+			continue
+		}
+
+		if m := adocAnchorRe.FindStringSubmatch(trim); m != nil {
+			flush()
+			id = m[1] + m[2]
+			continue
+		}
+		if m := adocBlockAttrRe.FindStringSubmatch(trim); m != nil && len(paragraph) == 0 {
+			flush()
+			blockAttr = m[1]
+			continue
+		}
+
+		if m := adocHeadingRe.FindStringSubmatch(l); m != nil {
+			flush()
+			title := strings.TrimSpace(m[2])
+			if id == "" {
+				id = adocId(title)
+			}
+			nodes = append(nodes, adocEl("h"+string(rune('0'+len(m[1]))), map[string]interface{}{"id": id}, adocInline(title)...))
+			id = ""
+			continue
+		}
+
+		switch trim {
+		case "----":
+			flush()
+			code := strings.Join(p.readDelimited(l), "\n")
+			var attr map[string]interface{}
+			if as := strings.Split(blockAttr, ","); len(as) > 1 && strings.TrimSpace(as[0]) == "source" {
+				attr = map[string]interface{}{"class": "language-" + strings.TrimSpace(as[1])}
+			}
+			nodes = append(nodes, adocEl("pre", nil, adocEl("code", attr, code)))
+			blockAttr = ""
+			continue
+		case "....":
+			flush()
+			nodes = append(nodes, adocEl("pre", nil, strings.Join(p.readDelimited(l), "\n")))
+			continue
+		case "____":
+			flush()
+			sub := adocParser{lines: p.readDelimited(l)}
+			nodes = append(nodes, adocEl("blockquote", nil, sub.parse()...))
+			continue
+		case "'''":
+			flush()
+			nodes = append(nodes, adocEl("hr", nil))
+			continue
+		}
+
+		if m := adocImageRe.FindStringSubmatch(trim); m != nil {
+			flush()
+			nodes = append(nodes, adocEl("img", map[string]interface{}{"src": m[1], "alt": m[2]}))
+			continue
+		}
+
+		if m := adocListRe.FindStringSubmatch(trim); m != nil && len(paragraph) == 0 {
+			if list == nil {
+				list = &adocList{}
+			}
+			list.add(m[1], adocInline(m[2]))
+			continue
+		}
+		if list != nil {
+			// 列表项的续行
+			list.appendText(adocInline(" " + trim))
+			continue
+		}
+
+		blockAttr = ""
+		paragraph = append(paragraph, trim)
+	}
+	flush()
+
+	return nodes
+}
+
+// adocList 使用标记的长度表示嵌套层级，如 * 与 **
+type adocList struct {
+	stack []adocListLevel
+}
+
+type adocListLevel struct {
+	marker string
+	list   jsx.VDom
+	items  []interface{}
+}
+
+func (l *adocList) add(marker string, children []interface{}) {
+	// 回到相同标记的层级
+	for i := len(l.stack) - 1; i >= 0; i-- {
+		if l.stack[i].marker == marker {
+			l.closeTo(i + 1)
+			l.stack[i].items = append(l.stack[i].items, adocEl("li", nil, children...))
+			return
+		}
+	}
+
+	tag := "ul"
+	if strings.HasPrefix(marker, ".") {
+		tag = "ol"
+	}
+	l.stack = append(l.stack, adocListLevel{marker: marker, list: adocEl(tag, nil)})
+	top := &l.stack[len(l.stack)-1]
+	top.items = append(top.items, adocEl("li", nil, children...))
+}
+
+// appendText 将文本追加到最后一个列表项
+func (l *adocList) appendText(children []interface{}) {
+	top := l.stack[len(l.stack)-1]
+	li := top.items[len(top.items)-1].(jsx.VDom)
+	attr := li["attributes"].(map[string]interface{})
+	cs, _ := attr["children"].([]interface{})
+	attr["children"] = append(cs, children...)
+}
+
+// closeTo 关闭 n 层之后的列表，并将它们添加到上一层的最后一个列表项中
+func (l *adocList) closeTo(n int) {
+	for len(l.stack) > n {
+		top := l.stack[len(l.stack)-1]
+		l.stack = l.stack[:len(l.stack)-1]
+		top.list["attributes"].(map[string]interface{})["children"] = top.items
+
+		parent := l.stack[len(l.stack)-1]
+		li := parent.items[len(parent.items)-1].(jsx.VDom)
+		attr := li["attributes"].(map[string]interface{})
+		cs, _ := attr["children"].([]interface{})
+		attr["children"] = append(cs, top.list)
+	}
+}
+
+func (l *adocList) root() jsx.VDom {
+	l.closeTo(1)
+	root := l.stack[0]
+	root.list["attributes"].(map[string]interface{})["children"] = root.items
+	return root.list
+}
+
+// adocId 与 Asciidoctor 一样生成标题 id：以 _ 开头，非字母数字替换为 _
+func adocId(title string) string {
+	var sb strings.Builder
+	sb.WriteString("_")
+	lastUnderscore := true
+	for _, r := range strings.ToLower(title) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			sb.WriteRune(r)
+			lastUnderscore = false
+		} else if !lastUnderscore {
+			sb.WriteRune('_')
+			lastUnderscore = true
+		}
+	}
+	return strings.TrimRight(sb.String(), "_")
+}
+
+// adocInline 解析行内格式
+func adocInline(s string) []interface{} {
+	var nodes []interface{}
+	last := 0
+	for _, m := range adocInlineRe.FindAllStringSubmatchIndex(s, -1) {
+		if m[0] > last {
+			nodes = append(nodes, s[last:m[0]])
+		}
+		last = m[1]
+
+		group := func(i int) (string, bool) {
+			if m[2*i] < 0 {
+				return "", false
+			}
+			return s[m[2*i]:m[2*i+1]], true
+		}
+
+		if code, ok := group(1); ok {
+			nodes = append(nodes, adocEl("code", nil, code))
+		} else if src, ok := group(2); ok {
+			alt, _ := group(3)
+			nodes = append(nodes, adocEl("img", map[string]interface{}{"src": src, "alt": alt}))
+		} else if href, ok := group(4); ok {
+			text, _ := group(5)
+			nodes = append(nodes, adocLink(href, text))
+		} else if href, ok := group(6); ok {
+			text, _ := group(7)
+			nodes = append(nodes, adocLink(href, text))
+		} else if strong, ok := group(8); ok {
+			nodes = append(nodes, adocEl("strong", nil, adocInline(strong)...))
+		} else if em, ok := group(9); ok {
+			nodes = append(nodes, adocEl("em", nil, adocInline(em)...))
+		}
+	}
+	if last < len(s) {
+		nodes = append(nodes, s[last:])
+	}
+	return nodes
+}
+
+func adocLink(href, text string) jsx.VDom {
+	if text == "" {
+		text = href
+	}
+	return adocEl("a", map[string]interface{}{"href": href}, adocInline(text)...)
+}
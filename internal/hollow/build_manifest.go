@@ -0,0 +1,265 @@
+package hollow
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/go-git/go-billy/v5"
+	"github.com/zbysir/hollow/internal/pkg/util"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// buildManifestFile 保存在 dst 中，记录上一次构建的产物，用于增量构建。
+// 放在 dst 而不是 CacheFs 中，是因为它描述的是 dst 的状态，dst 被清空时它也会一起被清空。
+const buildManifestFile = ".hollow-manifest.json"
+
+// buildManifestVersion 当 manifest 结构或渲染逻辑变化时需要增加，旧的 manifest 会被丢弃。
+const buildManifestVersion = 3
+
+// configFiles 是所有可能的配置文件，任何一个变化都会导致全量构建。
+var configFiles = []string{"config.ts", "config.js", "config.yml"}
+
+type buildManifest struct {
+	Version int                          `json:"version"`
+	Theme   string                       `json:"theme"`             // 主题所有文件的 hash
+	Global  map[string]string            `json:"global"`            // 加载配置与主题（执行主题顶层代码）时读取的 source 文件与其 hash，任何变化都会导致所有页面重新渲染
+	Pages   map[string]buildManifestPage `json:"pages"`             // key 为输出文件路径
+	Drafts  bool                         `json:"drafts,omitempty"`  // 是否显示了草稿等未发布的内容
+	Expire  int64                        `json:"expire,omitempty"`  // 定时发布或者过期的内容状态变化的时间（unix 秒），到达后需要全量构建
+	Outputs []string                     `json:"outputs,omitempty"` // 加载主题与页面列表时产生的页面之外的文件
+}
+
+type buildManifestPage struct {
+	Hash    string            `json:"hash"`              // 输出内容的 hash
+	Params  string            `json:"params,omitempty"`  // 动态页面参数的 hash
+	Sources map[string]string `json:"sources"`           // 渲染页面时读取的 source 文件与其 hash，包括内容中引用的图片等文件
+	Outputs []string          `json:"outputs,omitempty"` // 渲染页面时产生的页面之外的文件，如复制到 __source 下的图片与生成的图片版本
+}
+
+func newBuildManifest() *buildManifest {
+	return &buildManifest{
+		Version: buildManifestVersion,
+		Global:  map[string]string{},
+		Pages:   map[string]buildManifestPage{},
+	}
+}
+
+// readBuildManifest 读取 dst 中的 manifest，不存在或者无法解析时返回 nil，表示需要全量构建。
+func readBuildManifest(dst billy.Filesystem) *buildManifest {
+	f, err := dst.Open(buildManifestFile)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	var m buildManifest
+	err = json.NewDecoder(f).Decode(&m)
+	if err != nil {
+		return nil
+	}
+	if m.Version != buildManifestVersion {
+		return nil
+	}
+
+	return &m
+}
+
+func (m *buildManifest) write(dst billy.Filesystem) error {
+	bs, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	f, err := dst.Create(buildManifestFile)
+	if err != nil {
+		return fmt.Errorf("create file '%v' error: %w", buildManifestFile, err)
+	}
+	defer f.Close()
+
+	_, err = f.Write(bs)
+	return err
+}
+
+// reusable 返回上一次构建的页面是否可以复用，只有主题与全局依赖都没有变化时才可以。
+func (m *buildManifest) reusable(next *buildManifest) bool {
+	if m == nil {
+		return false
+	}
+	if m.Theme != next.Theme || m.Drafts != next.Drafts {
+		return false
+	}
+	if m.Expire != 0 && time.Now().Unix() >= m.Expire {
+		return false
+	}
+
+	return sameHashes(m.Global, next.Global)
+}
+
+// page 返回上一次构建中 distFile 的记录
+func (m *buildManifest) page(distFile string) (buildManifestPage, bool) {
+	if m == nil {
+		return buildManifestPage{}, false
+	}
+	p, ok := m.Pages[distFile]
+	return p, ok
+}
+
+// files 返回构建产生的所有文件，包括页面与页面之外的文件
+func (m *buildManifest) files() map[string]struct{} {
+	fs := map[string]struct{}{}
+	for _, o := range m.Outputs {
+		fs[o] = struct{}{}
+	}
+	for k, p := range m.Pages {
+		fs[k] = struct{}{}
+		for _, o := range p.Outputs {
+			fs[o] = struct{}{}
+		}
+	}
+	return fs
+}
+
+// stale 返回上一次构建产生，但这一次构建不再产生的文件
+func (m *buildManifest) stale(next *buildManifest) []string {
+	if m == nil {
+		return nil
+	}
+	files := next.files()
+	var s []string
+	for k := range m.files() {
+		if _, ok := files[k]; !ok {
+			s = append(s, k)
+		}
+	}
+	sort.Strings(s)
+	return s
+}
+
+func sameHashes(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if bv, ok := b[k]; !ok || bv != v {
+			return false
+		}
+	}
+	return true
+}
+
+// sourceHasher 计算 source 文件的 hash，同一次构建中每个文件只会计算一次。
+type sourceHasher struct {
+	fs    fs.FS
+	cache map[string]string
+}
+
+func newSourceHasher(f fs.FS) *sourceHasher {
+	return &sourceHasher{fs: f, cache: map[string]string{}}
+}
+
+// hash 返回文件内容的 hash；如果是文件夹则返回其中所有文件路径的 hash，用于发现新增与删除的文件；不存在则返回空字符串。
+func (h *sourceHasher) hash(name string) string {
+	name = cleanSourcePath(name)
+	if v, ok := h.cache[name]; ok {
+		return v
+	}
+
+	var v string
+	stat, err := fs.Stat(h.fs, name)
+	if err == nil {
+		if stat.IsDir() {
+			var names []string
+			_ = fs.WalkDir(h.fs, name, func(p string, d fs.DirEntry, err error) error {
+				if err != nil {
+					return nil
+				}
+				names = append(names, p)
+				return nil
+			})
+			v = util.MD5(strings.Join(names, "\n"))
+		} else {
+			bs, err := fs.ReadFile(h.fs, name)
+			if err == nil {
+				v = util.MD5(string(bs))
+			}
+		}
+	}
+
+	h.cache[name] = v
+	return v
+}
+
+func (h *sourceHasher) hashAll(names []string) map[string]string {
+	m := make(map[string]string, len(names))
+	for _, n := range names {
+		m[cleanSourcePath(n)] = h.hash(n)
+	}
+	return m
+}
+
+// unchanged 返回记录的文件是否都没有变化
+func (h *sourceHasher) unchanged(sources map[string]string) bool {
+	for k, v := range sources {
+		if h.hash(k) != v {
+			return false
+		}
+	}
+	return true
+}
+
+func cleanSourcePath(name string) string {
+	name = path.Clean("/" + name)
+	name = strings.TrimPrefix(name, "/")
+	if name == "" {
+		name = "."
+	}
+	return name
+}
+
+// hashThemeFs 计算主题所有文件的 hash，主题中任意文件变化都会导致全量构建。
+func hashThemeFs(themeUrl string, themeFs fs.FS) (string, error) {
+	var sb strings.Builder
+	sb.WriteString(themeUrl)
+	err := fs.WalkDir(themeFs, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if d.Name() == ".git" {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		bs, err := fs.ReadFile(themeFs, p)
+		if err != nil {
+			return err
+		}
+		sb.WriteString("\n")
+		sb.WriteString(p)
+		sb.WriteString(":")
+		sb.WriteString(util.MD5(string(bs)))
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return util.MD5(sb.String()), nil
+}
+
+func existFile(f billy.Filesystem, name string) bool {
+	_, err := f.Stat(name)
+	return err == nil
+}
+
+// existFiles 返回 names 是否都存在
+func existFiles(f billy.Filesystem, names []string) bool {
+	for _, n := range names {
+		if !existFile(f, n) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,215 @@
+package hollow
+
+import (
+	"bytes"
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/zbysir/hollow/internal/pkg/log"
+	"strings"
+	"testing"
+)
+
+func TestIncrementalBuild(t *testing.T) {
+	theme := `
+import {getContentDetail} from "@bysir/hollow"
+
+const Post = (props) => {
+  const c = getContentDetail(props.path)
+  return <div dangerouslySetInnerHTML={{__html: c.content}}></div>
+}
+
+export default {
+  pages: [
+    {path: "a", component: () => <Post path="contents/a.md"/>},
+    {path: "b", component: () => <Post path="contents/b.md"/>},
+  ],
+  assets: [],
+}
+`
+	source := memfs.New()
+	writeTestFiles(t, source, map[string]string{
+		"config.yml":      "theme: theme\n",
+		"contents/a.md":   "# a",
+		"contents/b.md":   "# b",
+		"theme/index.jsx": theme,
+	})
+
+	dst := memfs.New()
+	build := func() string {
+		var buf bytes.Buffer
+		b, err := NewHollow(Option{SourceFs: source})
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = b.BuildToFs(NewRenderContext(), dst, ExecOption{
+			Log:         log.New(log.Options{To: &buf, DisableTime: true, DisableLevel: true, DisableCaller: true}),
+			Incremental: true,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return buf.String()
+	}
+
+	out := build()
+	assert.Contains(t, out, "Create file [000]: a/index.html")
+	assert.Contains(t, out, "Create file [001]: b/index.html")
+
+	out = build()
+	assert.Contains(t, out, "Skip file [000]: a/index.html")
+	assert.Contains(t, out, "Skip file [001]: b/index.html")
+
+	writeTestFile(t, source, "contents/a.md", "# a2")
+	out = build()
+	assert.Contains(t, out, "Create file [000]: a/index.html")
+	assert.Contains(t, out, "Skip file [001]: b/index.html")
+
+	// 主题变化会导致所有页面重新渲染，输出没有变化的页面不会重新写入
+	writeTestFile(t, source, "theme/index.jsx", strings.Replace(theme, `{path: "b", component: () => <Post path="contents/b.md"/>},`, "", 1))
+	out = build()
+	assert.Contains(t, out, "Unchanged file [000]: a/index.html")
+	assert.Contains(t, out, "Remove file: b/index.html")
+	assert.False(t, existFile(dst, "b/index.html"))
+}
+
+func TestIncrementalBuildDynamicPages(t *testing.T) {
+	source := memfs.New()
+	writeTestFiles(t, source, map[string]string{
+		"config.yml":    "theme: theme\n",
+		"contents/a.md": "# a",
+		"contents/b.md": "# b",
+		"theme/index.jsx": `
+import {getContents, getContentDetail} from "@bysir/hollow"
+
+export default {
+  pages: [
+    {path: "about", body: "about"},
+    {
+      path: "posts/:slug",
+      getPaths: () => getContents("contents").list.map(i => ({slug: i.name, title: i.meta.title})),
+      component: (params) => <div>{params.title}<div dangerouslySetInnerHTML={{__html: getContentDetail("contents/" + params.slug + ".md").content}}></div></div>,
+    },
+  ],
+  assets: [],
+}
+`,
+	})
+
+	dst := memfs.New()
+	build := func() string {
+		var buf bytes.Buffer
+		b, err := NewHollow(Option{SourceFs: source})
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = b.BuildToFs(NewRenderContext(), dst, ExecOption{
+			Log:         log.New(log.Options{To: &buf, DisableTime: true, DisableLevel: true, DisableCaller: true}),
+			Incremental: true,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return buf.String()
+	}
+
+	out := build()
+	assert.Contains(t, out, "Create file [000]: about/index.html")
+	assert.Contains(t, out, "Create file [001]: posts/a/index.html")
+	assert.Contains(t, out, "Create file [002]: posts/b/index.html")
+
+	// getPaths 读取的文件不会导致所有页面重新渲染
+	writeTestFile(t, source, "contents/a.md", "# a2")
+	out = build()
+	assert.Contains(t, out, "Skip file [000]: about/index.html")
+	assert.Contains(t, out, "Create file [001]: posts/a/index.html")
+	assert.Contains(t, out, "Skip file [002]: posts/b/index.html")
+
+	// getPaths 返回的参数变化时页面会重新渲染
+	writeTestFile(t, source, "contents/b.md", "---\ntitle: B\n---\n# b")
+	writeTestFile(t, source, "contents/c.md", "# c")
+	out = build()
+	assert.Contains(t, out, "Skip file [000]: about/index.html")
+	assert.Contains(t, out, "Skip file [001]: posts/a/index.html")
+	assert.Contains(t, out, "Create file [002]: posts/b/index.html")
+	assert.Contains(t, out, "Create file [003]: posts/c/index.html")
+}
+
+func TestIncrementalBuildAssets(t *testing.T) {
+	source := memfs.New()
+	writeTestFiles(t, source, map[string]string{
+		"config.yml":         "theme: theme\n",
+		"contents/a.md":      "![x](./img/x.png)",
+		"contents/img/x.png": testPng(t, 20, 10),
+		"theme/index.jsx": `
+import {getContentDetail} from "@bysir/hollow"
+
+export default {
+  pages: [{path: "a", component: () => <div dangerouslySetInnerHTML={{__html: getContentDetail("contents/a.md").content}}></div>}],
+  assets: [],
+}
+`,
+	})
+
+	dst := memfs.New()
+	build := func() string {
+		var buf bytes.Buffer
+		b, err := NewHollow(Option{SourceFs: source})
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = b.BuildToFs(NewRenderContext(), dst, ExecOption{
+			Log:         log.New(log.Options{To: &buf, DisableTime: true, DisableLevel: true, DisableCaller: true}),
+			Incremental: true,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return buf.String()
+	}
+	variants := func() []string {
+		var vs []string
+		for _, o := range readBuildManifest(dst).Pages["a/index.html"].Outputs {
+			if strings.HasPrefix(o, imageVariantPrefix) {
+				vs = append(vs, o)
+			}
+		}
+		return vs
+	}
+
+	build()
+	assert.Equal(t, testPng(t, 20, 10), readTestFile(t, dst, "__source/contents/img/x.png"))
+
+	// 内容中引用的图片变化时重新渲染页面，并复制新的图片
+	writeTestFile(t, source, "contents/img/x.png", testPng(t, 30, 10))
+	out := build()
+	assert.Contains(t, out, "Unchanged file [000]: a/index.html")
+	assert.Equal(t, testPng(t, 30, 10), readTestFile(t, dst, "__source/contents/img/x.png"))
+
+	// 图片版本的地址中包含图片的 hash，图片变化后旧的版本会被删除
+	writeTestFile(t, source, "config.yml", "theme: theme\nimages:\n  enable: true\n  widths: [10]\n  formats: [png]\n")
+	build()
+	old := variants()
+	assert.Len(t, old, 2)
+	writeTestFile(t, source, "contents/img/x.png", testPng(t, 40, 10))
+	out = build()
+	assert.Contains(t, out, "Create file [000]: a/index.html")
+	vs := variants()
+	assert.Len(t, vs, 2)
+	for _, v := range vs {
+		assert.True(t, existFile(dst, v), v)
+	}
+	for _, v := range old {
+		assert.Contains(t, out, "Remove file: "+v)
+		assert.False(t, existFile(dst, v), v)
+	}
+
+	// 不再引用的图片会被删除
+	writeTestFile(t, source, "contents/a.md", "a")
+	out = build()
+	assert.Contains(t, out, "Create file [000]: a/index.html")
+	assert.Contains(t, out, "Remove file: __source/contents/img/x.png")
+	for _, v := range vs {
+		assert.False(t, existFile(dst, v), v)
+	}
+	assert.Nil(t, readBuildManifest(dst).Pages["a/index.html"].Outputs)
+}
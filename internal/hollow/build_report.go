@@ -0,0 +1,98 @@
+package hollow
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/dop251/goja"
+	"github.com/zbysir/gojsx"
+	"os"
+	"sort"
+	"strings"
+)
+
+// BuildReport 记录构建过程中的所有错误，用于 ExecOption.ContinueOnError 模式
+type BuildReport struct {
+	Pages    []BuildReportItem `json:"pages"`    // 渲染失败的页面
+	Contents []BuildReportItem `json:"contents"` // 加载失败、被替换为错误页面的内容
+}
+
+type BuildReportItem struct {
+	Path  string   `json:"path"` // 页面路径或者内容文件路径
+	Error string   `json:"error"`
+	Stack []string `json:"stack,omitempty"` // js 调用栈
+}
+
+func newBuildReportItem(path string, err error) BuildReportItem {
+	msg, stack := errorStack(err)
+	return BuildReportItem{
+		Path:  path,
+		Error: msg,
+		Stack: stack,
+	}
+}
+
+// failed 返回构建是否应该失败，strict 模式下加载失败的内容也会导致构建失败
+func (r *BuildReport) failed(strict bool) bool {
+	if len(r.Pages) != 0 {
+		return true
+	}
+	return strict && len(r.Contents) != 0
+}
+
+func (r *BuildReport) writeFile(name string) error {
+	bs, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	err = os.WriteFile(name, bs, 0644)
+	if err != nil {
+		return fmt.Errorf("write report '%v' error: %w", name, err)
+	}
+	return nil
+}
+
+// BuildError 在构建完成但存在错误时返回
+type BuildError struct {
+	Report BuildReport
+}
+
+func (e *BuildError) Error() string {
+	return fmt.Sprintf("build failed: %v pages failed to render, %v contents failed to load", len(e.Report.Pages), len(e.Report.Contents))
+}
+
+// errorStack 拆分 js 异常中的错误信息与调用栈，其他错误则没有调用栈
+func errorStack(err error) (msg string, stack []string) {
+	var gojaErr *goja.Exception
+	if errors.As(err, &gojaErr) {
+		err = gojsx.PrettifyException(gojaErr)
+	}
+
+	var jsErr *gojsx.Exception
+	if errors.As(err, &jsErr) {
+		for _, s := range jsErr.Stacks {
+			stack = append(stack, strings.TrimSpace(s))
+		}
+		return jsErr.Text, stack
+	}
+
+	return err.Error(), nil
+}
+
+// contentErrors 返回渲染过程中加载失败的内容，同一个文件只会返回一次
+func contentErrors(ctx *RenderContext) []BuildReportItem {
+	m := map[string]BuildReportItem{}
+	for _, i := range ctx.GetData("contentErrors") {
+		item := i.(BuildReportItem)
+		m[item.Path] = item
+	}
+
+	items := make([]BuildReportItem, 0, len(m))
+	for _, v := range m {
+		items = append(items, v)
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].Path < items[j].Path
+	})
+	return items
+}
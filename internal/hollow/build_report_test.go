@@ -0,0 +1,82 @@
+package hollow
+
+import (
+	"errors"
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestContinueOnError(t *testing.T) {
+	source := memfs.New()
+	writeTestFile(t, source, "config.yml", "theme: theme\n")
+	writeTestFile(t, source, "contents/a.md", "# a")
+	writeTestFile(t, source, "contents/b.mdx", "# b {undefinedVar.x}")
+	writeTestFile(t, source, "theme/index.jsx", `
+import {getContents} from "@bysir/hollow"
+
+const Boom = () => {
+  const x = null
+  return <div>{x.y}</div>
+}
+
+export default {
+  pages: [
+    {path: "list", component: () => <div>{getContents("contents").list.map(i => i.name).join(",")}</div>},
+    {path: "boom", component: () => <Boom/>},
+  ],
+  assets: [],
+}
+`)
+
+	b, err := NewHollow(Option{SourceFs: source})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dst := memfs.New()
+	err = b.BuildToFs(NewRenderContext(), dst, ExecOption{})
+	assert.ErrorContains(t, err, "render page 'boom' error")
+
+	err = b.BuildToFs(NewRenderContext(), dst, ExecOption{ContinueOnError: true})
+	var be *BuildError
+	if !errors.As(err, &be) {
+		t.Fatalf("expect BuildError, got: %v", err)
+	}
+	assert.True(t, existFile(dst, "list/index.html"))
+	assert.False(t, existFile(dst, "boom/index.html"))
+
+	if assert.Len(t, be.Report.Pages, 1) {
+		assert.Equal(t, "boom", be.Report.Pages[0].Path)
+		assert.Equal(t, "TypeError: Cannot read property 'y' of undefined", be.Report.Pages[0].Error)
+		assert.Contains(t, be.Report.Pages[0].Stack[0], "at Boom (index.jsx:6")
+	}
+	if assert.Len(t, be.Report.Contents, 1) {
+		assert.Equal(t, "contents/b.mdx", be.Report.Contents[0].Path)
+		assert.Equal(t, "ReferenceError: undefinedVar is not defined", be.Report.Contents[0].Error)
+	}
+
+	// 内容加载失败只在 strict 模式下导致构建失败
+	writeTestFile(t, source, "theme/index.jsx", `
+import {getContents} from "@bysir/hollow"
+
+export default {
+  pages: [
+    {path: "list", component: () => <div>{getContents("contents").list.map(i => i.name).join(",")}</div>},
+  ],
+  assets: [],
+}
+`)
+	b, err = NewHollow(Option{SourceFs: source})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = b.BuildToFs(NewRenderContext(), dst, ExecOption{ContinueOnError: true})
+	assert.NoError(t, err)
+
+	err = b.BuildToFs(NewRenderContext(), dst, ExecOption{Strict: true})
+	if assert.ErrorAs(t, err, &be) {
+		assert.Len(t, be.Report.Pages, 0)
+		assert.Len(t, be.Report.Contents, 1)
+	}
+}
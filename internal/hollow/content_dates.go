@@ -0,0 +1,56 @@
+package hollow
+
+import (
+	"errors"
+	"github.com/go-git/go-git/v5"
+	hgit "github.com/zbysir/hollow/internal/pkg/git"
+	"github.com/zbysir/hollow/internal/pkg/log"
+	"io/fs"
+	"path"
+	"sync"
+	"time"
+)
+
+// jsDateLayout 与 meta 中的日期格式相同，主题中可以直接使用 new Date() 解析
+const jsDateLayout = "Mon Jan 02 2006 15:04:05 GMT-0700 (MST)"
+
+// fileDates 保存在 RenderContext 中，不会像 cache 一样被淘汰，同一次构建只会遍历一次提交历史
+type fileDates struct {
+	once  sync.Once
+	dates map[string]hgit.FileDate
+}
+
+func (b *Hollow) gitFileDates(ctx *RenderContext) map[string]hgit.FileDate {
+	d := ctx.fileDates
+	d.once.Do(func() {
+		end := ctx.timerStart("gitFileDates")
+		defer end()
+
+		var err error
+		d.dates, err = hgit.FileDates(b.SourceFs)
+		if err != nil && !errors.Is(err, git.ErrRepositoryNotExists) {
+			log.Warnf("read git dates error: %v", err)
+		}
+	})
+	return d.dates
+}
+
+// withDates 使用 git 提交历史设置内容的创建与更新时间，没有提交过的文件使用文件修改时间
+func (b *Hollow) withDates(ctx *RenderContext, conf HollowConfig, file string, c Content) Content {
+	if !conf.GitDates {
+		return c
+	}
+
+	var created, updated time.Time
+	if d, ok := b.gitFileDates(ctx)[cleanSourcePath(file)]; ok {
+		created, updated = d.Created, d.Updated
+	} else if stat, err := fs.Stat(b.sourceStdFs, path.Clean(file)); err == nil {
+		created, updated = stat.ModTime(), stat.ModTime()
+	}
+	if !created.IsZero() {
+		// 提交时间带有作者的时区，统一转为本地时间
+		c.Created = created.Local().Format(jsDateLayout)
+		c.Updated = updated.Local().Format(jsDateLayout)
+	}
+	return c
+}
@@ -0,0 +1,89 @@
+package hollow
+
+import (
+	"fmt"
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/go-git/go-billy/v5/osfs"
+	"github.com/go-git/go-billy/v5/util"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestGitDates(t *testing.T) {
+	dir := t.TempDir()
+	r, err := git.PlainInit(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wt, err := r.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	source := osfs.New(dir)
+	writeTestFile(t, source, "config.yml", "theme: theme\ngit_dates: true\n")
+	writeTestFile(t, source, "theme/index.jsx", `
+import {getContents} from "@bysir/hollow"
+
+export default {
+  pages: [{path: "", component: () => <ul>{getContents("contents").list.map(c => <li>{c.name}|{c.created}|{c.updated}</li>)}</ul>}],
+  assets: [],
+}
+`)
+	t1 := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	t2 := time.Date(2022, 2, 1, 0, 0, 0, 0, time.UTC)
+	for _, when := range []time.Time{t1, t2} {
+		writeTestFile(t, source, "contents/a.md", "a "+when.String())
+		if _, err = wt.Add("contents/a.md"); err != nil {
+			t.Fatal(err)
+		}
+		_, err = wt.Commit("update a", &git.CommitOptions{Author: &object.Signature{Name: "test", When: when}})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 没有提交的文件使用修改时间
+	t3 := time.Date(2022, 3, 1, 0, 0, 0, 0, time.UTC)
+	writeTestFile(t, source, "contents/b.md", "b")
+	if err = os.Chtimes(filepath.Join(dir, "contents/b.md"), t3, t3); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := NewHollow(Option{SourceFs: source})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dst := memfs.New()
+	err = b.BuildToFs(NewRenderContext(), dst, ExecOption{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	bs, err := util.ReadFile(dst, "index.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	date := func(t time.Time) string {
+		return t.Local().Format(jsDateLayout)
+	}
+	assert.Equal(t, "<ul><li>a|"+date(t1)+"|"+date(t2)+"</li><li>b|"+date(t3)+"|"+date(t3)+"</li></ul>", string(bs))
+
+	// 同一个 RenderContext（包括 fork 出的）只会读取一次提交历史，不会因为 cache 淘汰而重新读取
+	ctx := NewRenderContext()
+	assert.Len(t, b.gitFileDates(ctx), 1)
+	for i := 0; i < 200; i++ {
+		ctx.cache.Add(fmt.Sprintf("key-%v", i), i)
+	}
+	if _, err = wt.Add("contents/b.md"); err != nil {
+		t.Fatal(err)
+	}
+	_, err = wt.Commit("add b", &git.CommitOptions{Author: &object.Signature{Name: "test", When: t3}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, b.gitFileDates(ctx.fork()), 1)
+	assert.Len(t, b.gitFileDates(NewRenderContext()), 2)
+}
@@ -8,7 +8,6 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
-	"time"
 )
 
 type ContentLoader interface {
@@ -19,6 +18,11 @@ type MDLoader struct {
 	assets Assets
 	jsx    *jsx.Jsx
 	module map[string]map[string]interface{}
+	images *imagePipeline // 为图片生成响应式版本，没有开启时为 nil
+
+	diagrams  *diagramRenderer // 将图形代码块渲染为 svg
+	highlight *codeHighlighter // 高亮代码块，没有开启时为 nil
+	math      *mathRenderer    // 渲染数学公式，没有开启时为 nil
 }
 
 func NewMDLoader(assets Assets, jsx *jsx.Jsx, module map[string]map[string]interface{}) *MDLoader {
@@ -50,6 +54,11 @@ func (r *relativeFs) Open(name string) (fs.File, error) {
 }
 
 func (m *MDLoader) replaceImgUrl(dom jsx.VDom, baseDir string) (as Assets) {
+	return replaceImgUrl(m.assets, dom, baseDir)
+}
+
+// replaceImgUrl 替换图片的相对路径，不在 assets 中的图片会通过 /__source 访问，返回这些图片的路径
+func replaceImgUrl(assets Assets, dom jsx.VDom, baseDir string) (as Assets) {
 	walkVDom(dom, func(d jsx.VDom) {
 		i := d["nodeName"]
 		nodeName, _ := i.(string)
@@ -68,7 +77,7 @@ func (m *MDLoader) replaceImgUrl(dom jsx.VDom, baseDir string) (as Assets) {
 
 			var inAssets bool
 			// 移除 assets 文件夹前缀
-			for _, a := range m.assets {
+			for _, a := range assets {
 				if strings.HasPrefix(src, "/"+a) {
 					src = strings.TrimPrefix(src, "/"+a)
 					inAssets = true
@@ -115,7 +124,7 @@ func (m *MDLoader) Load(filePath string, withContent bool) (Content, error) {
 	if err != nil {
 		return Content{}, err
 	}
-	fileDir, name := filepath.Split(filePath)
+	fileDir := filepath.Dir(filePath)
 	if !strings.HasPrefix(fileDir, "/") {
 		fileDir = "/" + fileDir
 	}
@@ -123,13 +132,7 @@ func (m *MDLoader) Load(filePath string, withContent bool) (Content, error) {
 	metai := e.Exports["meta"]
 	meta, _ := metai.(map[string]interface{})
 
-	for k, v := range meta {
-		switch t := v.(type) {
-		case time.Time:
-			// 格式化为 Mon Jan 02 2006 15:04:05 GMT-0700 (MST) 格式，前端才能处理
-			meta[k] = t.Format("Mon Jan 02 2006 15:04:05 GMT-0700 (MST)")
-		}
-	}
+	formatMetaDates(meta)
 
 	var dom jsx.VDom
 	switch t := e.Default.(type) {
@@ -139,29 +142,18 @@ func (m *MDLoader) Load(filePath string, withContent bool) (Content, error) {
 		panic(t)
 	}
 	assets := m.replaceImgUrl(dom, fileDir)
+	m.images.rewrite(dom)
+	m.diagrams.rewrite(dom)
+	m.highlight.rewrite(dom)
+	m.math.rewrite(dom)
 	replaceAttrDot(dom)
 
 	tocItem := generateTOC(dom)
 	if err != nil {
 		return Content{}, err
 	}
-	content := dom.Render()
-
-	ext := filepath.Ext(filePath)
-	name = strings.TrimSuffix(name, ext)
-
-	return Content{
-		Name: name,
-		GetContent: func(opt GetContentOpt) string {
-			return processContent(content, opt)
-		},
-		Meta:    meta,
-		Ext:     ext,
-		Content: content,
-		IsDir:   false,
-		Toc:     tocItem.Items,
-		Assets:  assets,
-	}, nil
+
+	return newContent(filePath, meta, dom, tocItem.Items, assets), nil
 }
 
 type TocItem struct {
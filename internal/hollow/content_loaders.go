@@ -0,0 +1,300 @@
+package hollow
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/dop251/goja"
+	jsx "github.com/zbysir/gojsx"
+	"golang.org/x/net/html"
+	htmlatom "golang.org/x/net/html/atom"
+	"gopkg.in/yaml.v3"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ContentLoaderFactory 为一次渲染创建 ContentLoader
+type ContentLoaderFactory func(ctx *RenderContext) ContentLoader
+
+// builtinContentLoaders 返回内置的内容加载器，key 为扩展名
+func (b *Hollow) builtinContentLoaders() map[string]ContentLoaderFactory {
+	md := func(ctx *RenderContext) ContentLoader {
+		c, _ := b.LoadConfig(ctx)
+		l := NewMDLoader(c.Hollow.Assets, b.markdownJsx(ctx), map[string]map[string]interface{}{
+			// 在 mdx 中，也可以使用 hollow
+			"@bysir/hollow": b.ExportFunc(ctx),
+		})
+		l.images = b.imagePipeline(ctx, c.Hollow)
+		l.diagrams = b.diagrams()
+		l.highlight = newCodeHighlighter(c.Hollow.Highlight)
+		l.math = newMathRenderer(c.Hollow.Math)
+		return l
+	}
+	html := func(ctx *RenderContext) ContentLoader {
+		return NewHtmlLoader(b.sourceStdFs)
+	}
+	txt := func(ctx *RenderContext) ContentLoader {
+		return NewTextLoader(b.sourceStdFs)
+	}
+	adoc := func(ctx *RenderContext) ContentLoader {
+		c, _ := b.LoadConfig(ctx)
+		l := NewAsciiDocLoader(b.sourceStdFs, c.Hollow.Assets)
+		l.images = b.imagePipeline(ctx, c.Hollow)
+		l.diagrams = b.diagrams()
+		l.highlight = newCodeHighlighter(c.Hollow.Highlight)
+		return l
+	}
+
+	return map[string]ContentLoaderFactory{
+		".md":       md,
+		".mdx":      md,
+		".html":     html,
+		".htm":      html,
+		".txt":      txt,
+		".adoc":     adoc,
+		".asciidoc": adoc,
+	}
+}
+
+// RegisterContentLoader 注册扩展名（如 .org）对应的内容加载器，会覆盖内置的加载器。
+// 主题中导出的 loaders 优先级更高。
+func (b *Hollow) RegisterContentLoader(ext string, f ContentLoaderFactory) {
+	b.contentLoaderLock.Lock()
+	defer b.contentLoaderLock.Unlock()
+	b.contentLoaders[ext] = f
+}
+
+// formatMetaDates 将 meta 中的日期格式化为 Mon Jan 02 2006 15:04:05 GMT-0700 (MST) 格式，前端才能处理
+func formatMetaDates(meta map[string]interface{}) {
+	for k, v := range meta {
+		switch t := v.(type) {
+		case time.Time:
+			meta[k] = t.Format(jsDateLayout)
+		}
+	}
+}
+
+// splitFrontMatter 分割文件头部 --- 之间的 yaml meta 与正文，没有 meta 时 meta 为 nil
+func splitFrontMatter(body []byte) (map[string]interface{}, []byte, error) {
+	body = trapBOM(body)
+	if !bytes.HasPrefix(body, []byte("---\n")) {
+		return nil, body, nil
+	}
+	bbs := bytes.SplitN(body, []byte("---"), 3)
+	if len(bbs) <= 2 {
+		return nil, body, nil
+	}
+
+	var meta = map[string]interface{}{}
+	err := yaml.Unmarshal(bbs[1], &meta)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unmarshal meta error: %w", err)
+	}
+	return meta, bytes.TrimLeft(bbs[2], "\r\n"), nil
+}
+
+// readFrontMatterFile 读取文件，返回 meta 与正文
+func readFrontMatterFile(f fs.FS, filePath string) (map[string]interface{}, []byte, error) {
+	body, err := fs.ReadFile(f, filePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	meta, body, err := splitFrontMatter(body)
+	if err != nil {
+		return nil, nil, err
+	}
+	if meta == nil {
+		meta = map[string]interface{}{}
+	}
+	formatMetaDates(meta)
+	return meta, body, nil
+}
+
+// newContent 使用 VDom 创建 Content，顶层的 <!--more--> 分隔符会被移除，之前的内容作为摘要
+func newContent(filePath string, meta map[string]interface{}, dom jsx.VDom, toc []*TocItem, assets Assets) Content {
+	before, hasMore := splitMore(dom)
+	return newRenderedContent(filePath, meta, dom.Render(), before, hasMore, toc, assets)
+}
+
+// newRenderedContent 使用渲染后的 html 创建 Content，hasMore 时使用 before 作为摘要
+func newRenderedContent(filePath string, meta map[string]interface{}, body string, before string, hasMore bool, toc []*TocItem, assets Assets) Content {
+	stats := getContentStats(body, before, hasMore)
+
+	ext := filepath.Ext(filePath)
+	name := strings.TrimSuffix(filepath.Base(filePath), ext)
+
+	return Content{
+		Name: name,
+		File: filePath,
+		GetContent: func(opt GetContentOpt) string {
+			return processContent(body, opt)
+		},
+		Meta:    meta,
+		Ext:     ext,
+		Content: body,
+		IsDir:   false,
+		Toc:     toc,
+		Assets:  assets,
+
+		WordCount:   stats.WordCount,
+		ReadingTime: stats.ReadingTime,
+		Excerpt:     stats.Excerpt,
+	}
+}
+
+// newHtmlContent 使用 html 创建 Content，目录从 html 中带有 id 的标题中解析
+func newHtmlContent(filePath string, meta map[string]interface{}, body string) (Content, error) {
+	dom, err := htmlToVDom(body)
+	if err != nil {
+		return Content{}, fmt.Errorf("parse html error: %w", err)
+	}
+	before, body, hasMore := splitMoreHtml(body)
+	return newRenderedContent(filePath, meta, body, before, hasMore, generateTOC(dom).Items, nil), nil
+}
+
+// htmlToVDom 解析 html 片段为 VDom，用于复用 generateTOC 等处理 VDom 的逻辑
+func htmlToVDom(s string) (jsx.VDom, error) {
+	nodes, err := html.ParseFragment(strings.NewReader(s), &html.Node{Type: html.ElementNode, Data: "body", DataAtom: htmlatom.Body})
+	if err != nil {
+		return nil, err
+	}
+	var children []interface{}
+	for _, n := range nodes {
+		if c := htmlNodeToVDom(n); c != nil {
+			children = append(children, c)
+		}
+	}
+	return jsx.VDom{"nodeName": "", "attributes": map[string]interface{}{"children": children}}, nil
+}
+
+func htmlNodeToVDom(n *html.Node) interface{} {
+	switch n.Type {
+	case html.TextNode:
+		return n.Data
+	case html.ElementNode:
+		attr := map[string]interface{}{}
+		for _, a := range n.Attr {
+			attr[a.Key] = a.Val
+		}
+		var children []interface{}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if v := htmlNodeToVDom(c); v != nil {
+				children = append(children, v)
+			}
+		}
+		if len(children) != 0 {
+			attr["children"] = children
+		}
+		return jsx.VDom{"nodeName": n.Data, "attributes": attr}
+	}
+	return nil
+}
+
+// HtmlLoader 加载 .html 文件，正文原样输出
+type HtmlLoader struct {
+	fs fs.FS
+}
+
+func NewHtmlLoader(fs fs.FS) *HtmlLoader {
+	return &HtmlLoader{fs: fs}
+}
+
+func (l *HtmlLoader) Load(filePath string, withContent bool) (Content, error) {
+	meta, body, err := readFrontMatterFile(l.fs, filePath)
+	if err != nil {
+		return Content{}, err
+	}
+	return newHtmlContent(filePath, meta, string(body))
+}
+
+// TextLoader 加载 .txt 文件，空行分割段落，段落中的换行会被保留
+type TextLoader struct {
+	fs fs.FS
+}
+
+func NewTextLoader(fs fs.FS) *TextLoader {
+	return &TextLoader{fs: fs}
+}
+
+func (l *TextLoader) Load(filePath string, withContent bool) (Content, error) {
+	meta, body, err := readFrontMatterFile(l.fs, filePath)
+	if err != nil {
+		return Content{}, err
+	}
+
+	var ps []interface{}
+	text := strings.ReplaceAll(string(body), "\r\n", "\n")
+	for _, p := range strings.Split(text, "\n\n") {
+		p = strings.Trim(p, "\n")
+		if strings.TrimSpace(p) == "" {
+			continue
+		}
+		var children []interface{}
+		for i, line := range strings.Split(p, "\n") {
+			if i != 0 {
+				children = append(children, jsx.VDom{"nodeName": "br", "attributes": map[string]interface{}{}})
+			}
+			children = append(children, line)
+		}
+		ps = append(ps, jsx.VDom{"nodeName": "p", "attributes": map[string]interface{}{"children": children}})
+	}
+	dom := jsx.VDom{"nodeName": "", "attributes": map[string]interface{}{"children": ps}}
+
+	return newContent(filePath, meta, dom, nil, nil), nil
+}
+
+// JsContentLoader 调用主题中导出的 loaders 函数加载内容，如：
+//
+//	loaders: {'.org': (source, path) => ({meta: {title: 'hello'}, content: '<p>hello</p>'})}
+type JsContentLoader struct {
+	fs   fs.FS
+	vm   *goja.Runtime
+	fn   goja.Callable
+	lock *sync.Mutex // 同一个主题的 js 运行时不能并发调用，而 getContents 会并发加载内容
+}
+
+func (l *JsContentLoader) Load(filePath string, withContent bool) (Content, error) {
+	bs, err := fs.ReadFile(l.fs, filePath)
+	if err != nil {
+		return Content{}, err
+	}
+
+	l.lock.Lock()
+	v, err := l.fn(goja.Undefined(), l.vm.ToValue(string(trapBOM(bs))), l.vm.ToValue(filePath))
+	var r interface{}
+	if err == nil {
+		r = v.Export()
+	}
+	l.lock.Unlock()
+	if err != nil {
+		return Content{}, err
+	}
+
+	m, ok := r.(map[string]interface{})
+	if !ok {
+		return Content{}, fmt.Errorf("loader must return an object like {meta, content}, actual %T", r)
+	}
+	meta, _ := m["meta"].(map[string]interface{})
+	if meta == nil {
+		meta = map[string]interface{}{}
+	}
+	formatMetaDates(meta)
+	body, _ := m["content"].(string)
+
+	return newHtmlContent(filePath, meta, body)
+}
+
+// themeContentLoaders 返回主题中导出的内容加载器
+func (b *Hollow) themeContentLoaders(t ThemeExport) map[string]ContentLoader {
+	if len(t.Loaders) == 0 {
+		return nil
+	}
+	lock := &sync.Mutex{}
+	ls := make(map[string]ContentLoader, len(t.Loaders))
+	for ext, fn := range t.Loaders {
+		ls[ext] = &JsContentLoader{fs: b.sourceStdFs, vm: t.vm, fn: fn, lock: lock}
+	}
+	return ls
+}
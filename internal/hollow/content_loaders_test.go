@@ -0,0 +1,128 @@
+package hollow
+
+import (
+	"fmt"
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/zbysir/hollow/internal/pkg/gobilly"
+	"testing"
+)
+
+func TestAsciiDocLoader(t *testing.T) {
+	f := memfs.New()
+	writeTestFile(t, f, "contents/a.adoc", `= Hello AsciiDoc
+:author: bysir
+
+这是 *粗体* 与 _斜体_，还有 `+"`code`"+` 和 https://example.com[链接]。
+
+== 第一章
+
+* one
+** one.one
+* two
+
+[[custom]]
+=== Sub Section
+
+[source,go]
+----
+fmt.Println("<hi>")
+----
+
+NOTE: 注意
+
+image::img.png[图片]
+`)
+
+	c, err := NewAsciiDocLoader(gobilly.NewStdFs(f), Assets{"statics"}).Load("contents/a.adoc", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "Hello AsciiDoc", c.Meta["title"])
+	assert.Equal(t, "bysir", c.Meta["author"])
+	assert.Equal(t, `<p>这是 <strong>粗体</strong> 与 <em>斜体</em>，还有 <code>code</code> 和 <a href="https://example.com">链接</a>。</p>`+
+		`<h2 id="_第一章">第一章</h2>`+
+		`<ul><li>one<ul><li>one.one</li></ul></li><li>two</li></ul>`+
+		`<h3 id="custom">Sub Section</h3>`+
+		`<pre><code class="language-go">fmt.Println(&#34;&lt;hi&gt;&#34;)</code></pre>`+
+		`<div class="admonition note"><p>注意</p></div>`+
+		`<img alt="图片" src="/__source/contents/img.png"/>`, c.Content)
+	if assert.Len(t, c.Toc, 1) {
+		assert.Equal(t, "_第一章", c.Toc[0].Id)
+		assert.Equal(t, "custom", c.Toc[0].Items[0].Id)
+	}
+	assert.Equal(t, Assets{"/contents/img.png"}, c.Assets)
+}
+
+func TestContentLoaders(t *testing.T) {
+	body := buildSite(t, map[string]string{
+		"config.yml":         "theme: theme\n",
+		"contents/a.html":    "---\ntitle: A\n---\n<h2 id=\"x\">X</h2><script>if (1 < 2) {}</script>",
+		"contents/b.txt":     "line1\nline2 <b>\n\nparagraph",
+		"contents/c.org":     "#+TITLE: C\nhello org",
+		"contents/d.unknown": "d",
+		"theme/index.jsx": `
+import {getContents} from "@bysir/hollow"
+
+export default {
+  pages: [{path: "", component: () => <div>{getContents("contents").list.map(c => <p>{c.name}|{c.meta.title}|{c.content}|{JSON.stringify(c.toc)}</p>)}</div>}],
+  assets: [],
+  loaders: {
+    '.org': (source, path) => ({meta: {title: source.split('\n')[0].replace('#+TITLE: ', '')}, content: '<p>' + source.split('\n')[1] + '</p>'}),
+  },
+}
+`,
+	}, ExecOption{})
+	assert.Equal(t, `<div>`+
+		`<p>a|A|&lt;h2 id=&#34;x&#34;&gt;X&lt;/h2&gt;&lt;script&gt;if (1 &lt; 2) {}&lt;/script&gt;|[{&#34;title&#34;:&#34;X&#34;,&#34;items&#34;:[],&#34;id&#34;:&#34;x&#34;}]</p>`+
+		`<p>b||&lt;p&gt;line1&lt;br/&gt;line2 &amp;lt;b&amp;gt;&lt;/p&gt;&lt;p&gt;paragraph&lt;/p&gt;|[]</p>`+
+		`<p>c|C|&lt;p&gt;hello org&lt;/p&gt;|[]</p>`+
+		`</div>`, body)
+}
+
+func TestContentLoadersWorkers(t *testing.T) {
+	files := map[string]string{
+		"config.yml": "theme: theme\n",
+		"theme/index.jsx": `
+import {getContentDetail} from "@bysir/hollow"
+
+export default {
+  pages: [0, 1, 2, 3, 4, 5, 6, 7].map(i => ({
+    path: "p" + i,
+    component: () => <p>{getContentDetail("contents/" + i + ".org").meta.title}</p>,
+  })),
+  assets: [],
+  loaders: {
+    '.org': (source, path) => ({meta: {title: source.replace('#+TITLE: ', '')}, content: ''}),
+  },
+}
+`,
+	}
+	for i := 0; i < 8; i++ {
+		files[fmt.Sprintf("contents/%v.org", i)] = fmt.Sprintf("#+TITLE: %v", i)
+	}
+	b, f := newTestSite(t, files)
+
+	// 每个 worker 只调用自己的主题运行时中的加载器，使用 -race 检查
+	dst := buildTestSite(t, b, ExecOption{Workers: 4})
+	for i := 0; i < 8; i++ {
+		assert.Equal(t, fmt.Sprintf("<p>%v</p>", i), readTestFile(t, dst, fmt.Sprintf("p%v/index.html", i)))
+	}
+
+	// 在主题顶层读取内容时主题的加载器还没有安装
+	writeTestFile(t, f, "theme/index.jsx", `
+import {getContents} from "@bysir/hollow"
+
+const list = getContents("contents").list
+
+export default {
+  pages: [{path: "", component: () => <p>{list.length}</p>}],
+  assets: [],
+  loaders: {
+    '.org': (source, path) => ({meta: {}, content: ''}),
+  },
+}
+`)
+	err := b.BuildToFs(NewRenderContext(), memfs.New(), ExecOption{Workers: 4})
+	assert.ErrorContains(t, err, "contents are read at the top level of the theme before the loader of '.org' is installed")
+}
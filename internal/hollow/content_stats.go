@@ -0,0 +1,134 @@
+package hollow
+
+import (
+	jsx "github.com/zbysir/gojsx"
+	"math"
+	"strings"
+	"unicode"
+)
+
+const (
+	// excerptLen 是没有 <!--more--> 分隔符时摘要的最大字数
+	excerptLen = 200
+	// 阅读速度：中日韩文字按字计算，其他语言按单词计算
+	cjkPerMinute   = 400
+	wordsPerMinute = 200
+)
+
+// moreSeparator 是摘要分隔符，只有单独成段时生效，代码块等其他位置中的分隔符会原样保留
+const moreSeparator = "<!--more-->"
+
+type contentStats struct {
+	WordCount   int
+	ReadingTime int // 分钟
+	Excerpt     string
+}
+
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
+
+// countWords 统计字数，中日韩文字每个字算一个，其他语言按单词计算
+func countWords(text string) (cjk int, words int) {
+	inWord := false
+	for _, r := range text {
+		switch {
+		case isCJK(r):
+			cjk++
+			inWord = false
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if !inWord {
+				words++
+				inWord = true
+			}
+		case inWord && (r == '\'' || r == '-' || r == '_'):
+			// don't / well-known 算一个单词
+		default:
+			inWord = false
+		}
+	}
+	return
+}
+
+// readingTime 返回预计阅读时间（分钟），有内容时至少为 1 分钟
+func readingTime(cjk, words int) int {
+	if cjk+words == 0 {
+		return 0
+	}
+	m := math.Ceil(float64(cjk)/cjkPerMinute + float64(words)/wordsPerMinute)
+	return int(m)
+}
+
+// plainText 返回 html 的纯文本，连续的空白会被合并为一个空格
+func plainText(html string) string {
+	return strings.Join(strings.Fields(processContent(html, GetContentOpt{Pure: true})), " ")
+}
+
+// isMoreSeparator 返回顶层节点是否为 <!--more--> 分隔符，
+// md 中的 html 注释是文本节点，AsciiDoc 与 txt 中则是只包含分隔符的段落
+func isMoreSeparator(n interface{}) bool {
+	var d map[string]interface{}
+	switch t := n.(type) {
+	case string:
+		return strings.TrimSpace(t) == moreSeparator
+	case jsx.VDom:
+		d = t
+	case map[string]interface{}:
+		d = t
+	default:
+		return false
+	}
+	if d["nodeName"] != "p" {
+		return false
+	}
+	c, _ := lookupMapI(d, "attributes", "children")
+	if cs, ok := c.([]interface{}); ok && len(cs) == 1 {
+		c = cs[0]
+	}
+	text, ok := c.(string)
+	return ok && strings.TrimSpace(text) == moreSeparator
+}
+
+// splitMore 移除 dom 顶层的 <!--more--> 分隔符，返回分隔符之前的内容渲染后的 html
+func splitMore(dom jsx.VDom) (before string, ok bool) {
+	attr, _ := dom["attributes"].(map[string]interface{})
+	children, _ := attr["children"].([]interface{})
+	for i, c := range children {
+		if !isMoreSeparator(c) {
+			continue
+		}
+		before = jsx.VDom{"nodeName": "", "attributes": map[string]interface{}{"children": children[:i]}}.Render()
+		attr["children"] = append(append([]interface{}{}, children[:i]...), children[i+1:]...)
+		return before, true
+	}
+	return "", false
+}
+
+// splitMoreHtml 使用 html 中的 <!--more--> 注释分割内容，返回分隔符之前的部分与移除分隔符后的内容，
+// 代码块中的分隔符已经被转义，不会被匹配
+func splitMoreHtml(content string) (before string, rest string, ok bool) {
+	if i := strings.Index(content, moreSeparator); i != -1 {
+		return content[:i], content[:i] + content[i+len(moreSeparator):], true
+	}
+	return "", content, false
+}
+
+// getContentStats 计算内容的字数、阅读时间与摘要，content 为渲染后的 html，
+// hasMore 时使用 <!--more--> 之前的内容 before 作为摘要。
+func getContentStats(content string, before string, hasMore bool) contentStats {
+	text := plainText(content)
+	cjk, words := countWords(text)
+	s := contentStats{
+		WordCount:   cjk + words,
+		ReadingTime: readingTime(cjk, words),
+	}
+
+	if hasMore {
+		s.Excerpt = plainText(before)
+	} else if r := []rune(text); len(r) > excerptLen {
+		s.Excerpt = string(r[:excerptLen]) + "..."
+	} else {
+		s.Excerpt = text
+	}
+	return s
+}
@@ -0,0 +1,86 @@
+package hollow
+
+import (
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/go-git/go-billy/v5/util"
+	"github.com/stretchr/testify/assert"
+	jsx "github.com/zbysir/gojsx"
+	"github.com/zbysir/hollow/internal/pkg/gobilly"
+	"strings"
+	"testing"
+)
+
+func TestCountWords(t *testing.T) {
+	cases := []struct {
+		text  string
+		cjk   int
+		words int
+	}{
+		{"", 0, 0},
+		{"hello world", 0, 2},
+		{"don't stop, well-known 2023", 0, 4},
+		{"你好，世界", 4, 0},
+		{"使用 Go 编写的 blog 生成器", 8, 2},
+		{"こんにちは 안녕", 7, 0},
+	}
+	for _, c := range cases {
+		cjk, words := countWords(c.text)
+		assert.Equal(t, c.cjk, cjk, c.text)
+		assert.Equal(t, c.words, words, c.text)
+	}
+
+	assert.Equal(t, 0, readingTime(0, 0))
+	assert.Equal(t, 1, readingTime(10, 10))
+	assert.Equal(t, 2, readingTime(400, 100))
+}
+
+func TestContentStats(t *testing.T) {
+	f := memfs.New()
+	util.WriteFile(f, "more.md", []byte("---\ntitle: more\n---\n这是**摘要** hello\n\n<!--more-->\n\n这是正文"), 0644)
+	util.WriteFile(f, "long.md", []byte("---\ntitle: long\n---\n"+strings.Repeat("字", 300)), 0644)
+	util.WriteFile(f, "code.md", []byte("---\ntitle: code\n---\n写在代码块中的分隔符不生效\n\n```html\n<!--more-->\n```\n\n正文"), 0644)
+
+	jx, err := jsx.NewJsx(jsx.Option{Fs: gobilly.NewStdFs(f)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := NewMDLoader(Assets{"statics"}, jx, nil)
+
+	c, err := m.Load("more.md", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "这是摘要 hello", c.Excerpt)
+	assert.Equal(t, 9, c.WordCount)
+	assert.Equal(t, 1, c.ReadingTime)
+	assert.NotContains(t, c.Content, "more")
+
+	c, err = m.Load("long.md", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, strings.Repeat("字", excerptLen)+"...", c.Excerpt)
+	assert.Equal(t, 300, c.WordCount)
+
+	c, err = m.Load("code.md", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "写在代码块中的分隔符不生效<!--more--> 正文", c.Excerpt)
+	assert.Contains(t, c.Content, "&lt;!--more--&gt;")
+
+	// AsciiDoc 中单独成段的分隔符，html 中的注释
+	util.WriteFile(f, "more.adoc", []byte("= more\n\n摘要\n\n<!--more-->\n\n正文\n"), 0644)
+	util.WriteFile(f, "more.html", []byte("<p>摘要</p><!--more--><pre>&lt;!--more--&gt;</pre>"), 0644)
+	for _, l := range []ContentLoader{NewAsciiDocLoader(gobilly.NewStdFs(f), nil), NewHtmlLoader(gobilly.NewStdFs(f))} {
+		name := "more.adoc"
+		if _, ok := l.(*HtmlLoader); ok {
+			name = "more.html"
+		}
+		c, err = l.Load(name, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, "摘要", c.Excerpt, name)
+	}
+}
@@ -0,0 +1,131 @@
+package hollow
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// 内容的发布状态，只有 published 状态的内容会出现在正式构建中
+const (
+	contentPublished = "published"
+	contentDraft     = "draft"     // meta 中 draft 为 true
+	contentScheduled = "scheduled" // publish_date（或者 publish_at）在当前时间之后
+	contentExpired   = "expired"   // expiry_date 在当前时间之前
+)
+
+// contentStatusChangeKey 记录渲染过程中遇到的内容状态变化时间，用于让增量构建在定时发布的文章发布时失效
+const contentStatusChangeKey = "contentStatusChange"
+
+func metaBool(v interface{}) bool {
+	switch t := v.(type) {
+	case bool:
+		return t
+	case string:
+		return t == "true"
+	}
+	return false
+}
+
+// publishDate 返回内容的定时发布时间，publish_at 是 publish_date 的别名
+func publishDate(meta map[string]interface{}) (time.Time, bool) {
+	for _, k := range []string{"publish_date", "publish_at"} {
+		if d, ok := parseDate(meta[k]); ok {
+			return d, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func contentStatus(meta map[string]interface{}, now time.Time) string {
+	if metaBool(meta["draft"]) {
+		return contentDraft
+	}
+	if d, ok := publishDate(meta); ok && d.After(now) {
+		return contentScheduled
+	}
+	if d, ok := parseDate(meta["expiry_date"]); ok && !d.After(now) {
+		return contentExpired
+	}
+	return contentPublished
+}
+
+// contentStatusChange 返回内容状态下一次变化的时间，即在 now 之后的发布时间或 expiry_date
+func contentStatusChange(meta map[string]interface{}, now time.Time) (time.Time, bool) {
+	var next time.Time
+	var dates []time.Time
+	if d, ok := publishDate(meta); ok {
+		dates = append(dates, d)
+	}
+	if d, ok := parseDate(meta["expiry_date"]); ok {
+		dates = append(dates, d)
+	}
+	for _, d := range dates {
+		if d.After(now) && (next.IsZero() || d.Before(next)) {
+			next = d
+		}
+	}
+	return next, !next.IsZero()
+}
+
+// contentVisible 返回内容是否需要显示，ShowDrafts 时显示所有内容
+func (b *Hollow) contentVisible(ctx *RenderContext, meta map[string]interface{}) bool {
+	now := time.Now()
+	if t, ok := contentStatusChange(meta, now); ok {
+		ctx.Save(contentStatusChangeKey, t)
+	}
+	return ctx.showDrafts || contentStatus(meta, now) == contentPublished
+}
+
+// nextStatusChange 返回渲染过程中遇到的最早的内容状态变化时间
+func nextStatusChange(ctx *RenderContext) (time.Time, bool) {
+	var next time.Time
+	for _, i := range ctx.GetData(contentStatusChangeKey) {
+		t := i.(time.Time)
+		if next.IsZero() || t.Before(next) {
+			next = t
+		}
+	}
+	return next, !next.IsZero()
+}
+
+type ScheduledContent struct {
+	File        string    `json:"file"`
+	Title       string    `json:"title"`
+	PublishDate time.Time `json:"publish_date"`
+}
+
+// ScheduledContents 返回 dir 下发布时间在 since 之后的内容（不包括草稿与已过期的内容），按照发布时间排序，
+// since 为当前时间时即还未到发布时间的内容。
+func (b *Hollow) ScheduledContents(ctx *RenderContext, dir string, since time.Time) ([]ScheduledContent, error) {
+	f := ctx.fork()
+	f.showDrafts = true
+
+	now := time.Now()
+	var list []ScheduledContent
+	for _, c := range b.getContents(f)(dir, getBlogOption{}).List {
+		if c.File == "" {
+			continue
+		}
+		d, ok := publishDate(c.Meta)
+		if !ok || !d.After(since) {
+			continue
+		}
+		if s := contentStatus(c.Meta, now); s == contentDraft || s == contentExpired {
+			continue
+		}
+		list = append(list, ScheduledContent{
+			File:        c.File,
+			Title:       firstNonEmpty(metaString(c.Meta, "title"), c.Name),
+			PublishDate: d,
+		})
+	}
+	if errs := f.GetData("contentErrors"); len(errs) != 0 {
+		return list, fmt.Errorf("load %v contents error: %v", len(errs), errs[0])
+	}
+
+	sort.SliceStable(list, func(i, j int) bool {
+		return list[i].PublishDate.Before(list[j].PublishDate)
+	})
+	return list, nil
+}
@@ -0,0 +1,82 @@
+package hollow
+
+import (
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/go-git/go-billy/v5/util"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestContentStatus(t *testing.T) {
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	for want, meta := range map[string]map[string]interface{}{
+		contentPublished: {"publish_date": "2022-12-31", "expiry_date": "2023-01-02"},
+		contentDraft:     {"draft": true, "publish_date": "2022-01-01"},
+		contentScheduled: {"publish_date": "Mon Jan 02 2023 15:04:05 GMT+0000 (UTC)"},
+		contentExpired:   {"expiry_date": now},
+	} {
+		assert.Equal(t, want, contentStatus(meta, now), meta)
+	}
+
+	next, ok := contentStatusChange(map[string]interface{}{"publish_date": "2022-01-01", "expiry_date": "2023-02-01"}, now)
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2023, 2, 1, 0, 0, 0, 0, time.UTC), next)
+	_, ok = contentStatusChange(map[string]interface{}{"draft": true}, now)
+	assert.False(t, ok)
+}
+
+func TestDrafts(t *testing.T) {
+	source := memfs.New()
+	writeTestFile(t, source, "config.yml", "theme: theme\n")
+	writeTestFile(t, source, "contents/a.md", "---\ntitle: A\n---\na")
+	writeTestFile(t, source, "contents/b.md", "---\ntitle: B\ndraft: true\n---\nb")
+	writeTestFile(t, source, "contents/c.md", "---\ntitle: C\npublish_date: 2999-01-01\n---\nc")
+	writeTestFile(t, source, "contents/d.md", "---\ntitle: D\nexpiry_date: 2000-01-01\n---\nd")
+	writeTestFile(t, source, "contents/e.md", "---\ntitle: E\npublish_date: 2998-01-01\n---\ne")
+	writeTestFile(t, source, "theme/index.jsx", `
+import {getContents} from "@bysir/hollow"
+
+export default {
+  pages: [{path: "", component: () => <p>{getContents("contents").list.map(c => c.name).join(",")}</p>}],
+  assets: [],
+}
+`)
+
+	b, err := NewHollow(Option{SourceFs: source})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for drafts, body := range map[bool]string{
+		false: "<p>a</p>",
+		true:  "<p>a,b,c,d,e</p>",
+	} {
+		dst := memfs.New()
+		err = b.BuildToFs(NewRenderContext(), dst, ExecOption{Incremental: true, ShowDrafts: drafts})
+		if err != nil {
+			t.Fatal(err)
+		}
+		bs, err := util.ReadFile(dst, "index.html")
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, body, string(bs))
+
+		// 定时发布的内容到达发布时间后不再复用上一次构建的页面
+		m := readBuildManifest(dst)
+		assert.Equal(t, time.Date(2998, 1, 1, 0, 0, 0, 0, time.UTC).Unix(), m.Expire)
+		assert.True(t, m.reusable(m))
+		m.Expire = time.Now().Unix()
+		assert.False(t, m.reusable(m))
+	}
+
+	list, err := b.ScheduledContents(NewRenderContext(), "contents", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []ScheduledContent{
+		{File: "contents/e.md", Title: "E", PublishDate: time.Date(2998, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{File: "contents/c.md", Title: "C", PublishDate: time.Date(2999, 1, 1, 0, 0, 0, 0, time.UTC)},
+	}, list)
+}
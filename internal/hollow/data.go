@@ -0,0 +1,207 @@
+package hollow
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/pelletier/go-toml/v2"
+	"github.com/zbysir/hollow/internal/pkg/log"
+	"gopkg.in/yaml.v3"
+	"io/fs"
+	"path"
+	"strings"
+	"time"
+)
+
+// dataDir 是存放数据文件的文件夹，其中的文件可以在主题中通过 getData 读取
+const dataDir = "data"
+
+var dataExts = []string{".yaml", ".yml", ".json", ".toml", ".csv"}
+
+// dataEntry 缓存在 RenderContext 中，命中缓存时也需要记录依赖
+type dataEntry struct {
+	value interface{}
+	deps  []string
+}
+
+// parseDataFile 根据扩展名解析数据文件，csv 文件的第一行为表头，返回对象数组
+func parseDataFile(name string, bs []byte) (interface{}, error) {
+	var v interface{}
+	var err error
+	switch path.Ext(name) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(bs, &v)
+	case ".json":
+		err = json.Unmarshal(bs, &v)
+	case ".toml":
+		err = toml.Unmarshal(bs, &v)
+	case ".csv":
+		v, err = parseCsv(bs)
+	default:
+		return nil, fmt.Errorf("unsupported data file '%v'", name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return normalizeData(v), nil
+}
+
+func parseCsv(bs []byte) ([]interface{}, error) {
+	records, err := csv.NewReader(bytes.NewReader(trapBOM(bs))).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	rows := []interface{}{}
+	if len(records) == 0 {
+		return rows, nil
+	}
+	header := records[0]
+	for _, r := range records[1:] {
+		row := make(map[string]interface{}, len(header))
+		for i, h := range header {
+			if i < len(r) {
+				row[h] = r[i]
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// normalizeData 将数据转换为 js 可以直接使用的类型：日期格式化为字符串，map 的 key 转为字符串
+func normalizeData(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, i := range t {
+			t[k] = normalizeData(i)
+		}
+		return t
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(t))
+		for k, i := range t {
+			m[fmt.Sprint(k)] = normalizeData(i)
+		}
+		return m
+	case []interface{}:
+		for k, i := range t {
+			t[k] = normalizeData(i)
+		}
+		return t
+	case time.Time:
+		return t.Format(jsDateLayout)
+	case toml.LocalDate, toml.LocalDateTime, toml.LocalTime:
+		return fmt.Sprint(t)
+	}
+	return v
+}
+
+// loadData 读取 data 文件夹中的数据，p 可以省略扩展名，p 为文件夹时返回以文件名（不含扩展名）为 key 的对象
+func (b *Hollow) loadData(p string) (v interface{}, deps []string, err error) {
+	rel := path.Clean("/" + p)
+	file := path.Join(dataDir, rel)
+
+	var candidates []string
+	if rel != "/" {
+		// getData('') 读取整个 data 文件夹
+		if ext := path.Ext(file); ext != "" {
+			candidates = []string{file}
+		}
+		for _, ext := range dataExts {
+			candidates = append(candidates, file+ext)
+		}
+	}
+	deps = append(deps, candidates...)
+
+	for _, c := range candidates {
+		bs, err := fs.ReadFile(b.sourceStdFs, c)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return nil, deps, err
+		}
+		v, err := parseDataFile(c, bs)
+		if err != nil {
+			return nil, deps, fmt.Errorf("parse data file '%v' error: %w", c, err)
+		}
+		return v, deps, nil
+	}
+
+	deps = append(deps, file)
+	items, err := fs.ReadDir(b.sourceStdFs, file)
+	if err != nil {
+		return nil, deps, err
+	}
+	m := map[string]interface{}{}
+	for _, item := range items {
+		name := item.Name()
+		if !item.IsDir() {
+			ext := path.Ext(name)
+			if !isDataExt(ext) {
+				continue
+			}
+			name = strings.TrimSuffix(name, ext)
+		}
+		sub, subDeps, err := b.loadData(path.Join(p, item.Name()))
+		deps = append(deps, subDeps...)
+		if err != nil {
+			return nil, deps, err
+		}
+		m[name] = sub
+	}
+	return m, deps, nil
+}
+
+// copyData 深拷贝数据，缓存中的数据不能直接返回给主题，否则在一个页面中修改数据会影响其他页面
+func copyData(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(t))
+		for k, i := range t {
+			m[k] = copyData(i)
+		}
+		return m
+	case []interface{}:
+		l := make([]interface{}, len(t))
+		for k, i := range t {
+			l[k] = copyData(i)
+		}
+		return l
+	}
+	return v
+}
+
+func isDataExt(ext string) bool {
+	for _, e := range dataExts {
+		if e == ext {
+			return true
+		}
+	}
+	return false
+}
+
+// getData 返回 data 文件夹中的数据，如 getData('authors') 读取 data/authors.yaml，找不到时返回 null。
+// 每次调用都返回新的拷贝，主题可以修改返回的数据
+func (b *Hollow) getData(ctx *RenderContext) func(p string) interface{} {
+	return func(p string) interface{} {
+		cacheKey := "data:" + p
+		if x, ok := ctx.cache.Get(cacheKey); ok {
+			e := x.(dataEntry)
+			ctx.dependOn(e.deps...)
+			return copyData(e.value)
+		}
+
+		v, deps, err := b.loadData(p)
+		ctx.dependOn(deps...)
+		if err != nil {
+			if !errors.Is(err, fs.ErrNotExist) {
+				log.Warnf("getData '%v' error: %v", p, err)
+			}
+			v = nil
+		}
+		ctx.cache.Add(cacheKey, dataEntry{value: v, deps: deps})
+		return copyData(v)
+	}
+}
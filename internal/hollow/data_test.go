@@ -0,0 +1,69 @@
+package hollow
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestGetData(t *testing.T) {
+	b, f := newTestSite(t, map[string]string{
+		"data/authors.yaml":       "bysir:\n  name: Bysir\n  joined: 2020-01-01\n",
+		"data/nav.json":           `[{"title": "Home", "href": "/"}]`,
+		"data/site.toml":          "title = \"Hollow\"\n[social]\ngithub = \"zbysir\"\n",
+		"data/projects/list.csv":  "name,stars\nhollow,100\ngojsx,50\n",
+		"data/projects/README.md": "ignored",
+	})
+	ctx := NewRenderContext()
+	getData := b.getData(ctx)
+
+	assert.Equal(t, map[string]interface{}{
+		"bysir": map[string]interface{}{"name": "Bysir", "joined": "Wed Jan 01 2020 00:00:00 GMT+0000 (UTC)"},
+	}, getData("authors"))
+	assert.Equal(t, []interface{}{map[string]interface{}{"title": "Home", "href": "/"}}, getData("nav.json"))
+	assert.Equal(t, map[string]interface{}{"title": "Hollow", "social": map[string]interface{}{"github": "zbysir"}}, getData("site"))
+	assert.Equal(t, map[string]interface{}{
+		"list": []interface{}{
+			map[string]interface{}{"name": "hollow", "stars": "100"},
+			map[string]interface{}{"name": "gojsx", "stars": "50"},
+		},
+	}, getData("projects"))
+	assert.Nil(t, getData("not-exist"))
+	assert.Contains(t, ctx.takeDeps(), "data/authors.yaml")
+
+	// 同一个 RenderContext 中会缓存数据，新的 RenderContext 会读取到修改后的文件
+	writeTestFile(t, f, "data/site.toml", "title = \"Changed\"\n")
+	assert.Equal(t, "Hollow", getData("site").(map[string]interface{})["title"])
+	assert.Equal(t, "Changed", b.getData(NewRenderContext())("site").(map[string]interface{})["title"])
+
+	writeTestFile(t, f, "config.yml", "theme: theme\n")
+	writeTestFile(t, f, "theme/index.jsx", `
+import {getData} from "@bysir/hollow"
+
+export default {
+  pages: [{path: "", component: () => <ul>{getData("nav").map(n => <li>{n.title}</li>)}</ul>}],
+  assets: [],
+}
+`)
+	assert.Equal(t, "<ul><li>Home</li></ul>", readTestFile(t, buildTestSite(t, b, ExecOption{}), "index.html"))
+
+	// 修改 getData 返回的数据不会影响其他页面
+	writeTestFile(t, f, "theme/index.jsx", `
+import {getData} from "@bysir/hollow"
+
+const Nav = () => {
+  const nav = getData("nav")
+  nav.push({title: "More"})
+  nav[0].title += "!"
+  return <ul>{nav.map(n => <li>{n.title}</li>)}</ul>
+}
+
+export default {
+  pages: [0, 1, 2, 3].map(i => ({path: "p" + i, component: Nav})),
+  assets: [],
+}
+`)
+	dst := buildTestSite(t, b, ExecOption{Workers: 4})
+	for _, p := range []string{"p0", "p1", "p2", "p3"} {
+		assert.Equal(t, "<ul><li>Home!</li><li>More</li></ul>", readTestFile(t, dst, p+"/index.html"), p)
+	}
+}
@@ -0,0 +1,185 @@
+package hollow
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/go-git/go-billy/v5"
+	"github.com/go-git/go-billy/v5/util"
+	jsx "github.com/zbysir/gojsx"
+	"github.com/zbysir/hollow/internal/pkg/log"
+	"os"
+	"oss.terrastruct.com/d2/d2layouts/d2dagrelayout"
+	"oss.terrastruct.com/d2/d2lib"
+	"oss.terrastruct.com/d2/d2renderers/d2svg"
+	"oss.terrastruct.com/d2/lib/textmeasure"
+	"path"
+	"sync"
+	"sync/atomic"
+)
+
+// DiagramRenderer 将代码块中的图形描述渲染为 svg
+type DiagramRenderer func(code string) (svg []byte, err error)
+
+// ErrDiagramUnavailable 表示渲染器当前不可用，如渲染器依赖的命令没有安装，此时代码块会保持原样
+var ErrDiagramUnavailable = errors.New("diagram renderer is unavailable")
+
+// builtinDiagramRenderers 返回内置的图形渲染器，key 为代码块的语言
+func builtinDiagramRenderers() map[string]DiagramRenderer {
+	return map[string]DiagramRenderer{
+		"d2": renderD2,
+	}
+}
+
+// RegisterDiagramRenderer 注册代码块语言（如 d2）对应的图形渲染器，会覆盖内置的渲染器。
+// 渲染器需要是纯函数：相同的代码总是渲染出相同的 svg，结果会按代码的 hash 缓存在 CacheFs 中。
+func (b *Hollow) RegisterDiagramRenderer(lang string, r DiagramRenderer) {
+	b.diagramLock.Lock()
+	defer b.diagramLock.Unlock()
+	b.diagramRenderers[lang] = r
+	// 渲染器变化后之前的错误不再有效
+	b.diagramErrors = &sync.Map{}
+}
+
+// d2Ruler 用于测量 d2 图形中文字的尺寸，创建时需要解析字体，所以只创建一次；Ruler 不支持并发使用
+var d2Ruler struct {
+	sync.Mutex
+	ruler *textmeasure.Ruler
+	err   error
+	once  sync.Once
+}
+
+// renderD2 使用 d2 库渲染 https://d2lang.com ，不依赖 d2 命令
+func renderD2(code string) ([]byte, error) {
+	d2Ruler.once.Do(func() {
+		d2Ruler.ruler, d2Ruler.err = textmeasure.NewRuler()
+	})
+	if d2Ruler.err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDiagramUnavailable, d2Ruler.err)
+	}
+
+	d2Ruler.Lock()
+	diagram, _, err := d2lib.Compile(context.Background(), code, &d2lib.CompileOptions{
+		Ruler:  d2Ruler.ruler,
+		Layout: d2dagrelayout.DefaultLayout,
+	})
+	d2Ruler.Unlock()
+	if err != nil {
+		return nil, fmt.Errorf("d2 error: %w", err)
+	}
+	return d2svg.Render(diagram, &d2svg.RenderOpts{Pad: d2svg.DEFAULT_PADDING})
+}
+
+// diagramRenderer 将内容中的图形代码块替换为 svg
+type diagramRenderer struct {
+	renderers map[string]DiagramRenderer
+	cacheFs   billy.Filesystem
+	cacheLock *sync.RWMutex
+	errors    *sync.Map // 渲染失败的代码块，key 为缓存文件名，避免开发时每次请求都重新渲染错误的图形
+}
+
+// diagrams 返回当前注册的图形渲染器
+func (b *Hollow) diagrams() *diagramRenderer {
+	b.diagramLock.RLock()
+	defer b.diagramLock.RUnlock()
+	renderers := make(map[string]DiagramRenderer, len(b.diagramRenderers))
+	for k, v := range b.diagramRenderers {
+		renderers[k] = v
+	}
+	return &diagramRenderer{renderers: renderers, cacheFs: b.CacheFs, cacheLock: &b.cacheLock, errors: b.diagramErrors}
+}
+
+// warnedDiagrams 记录已经提示过不可用的语言，避免每个代码块都输出一次警告
+var warnedDiagrams sync.Map
+
+// diagramTmpSeq 用于生成写入缓存时的临时文件名
+var diagramTmpSeq uint64
+
+// render 返回代码块对应的 svg，优先读取 CacheFs 中的缓存
+func (r *diagramRenderer) render(lang string, code string) ([]byte, error) {
+	h := sha256.Sum256([]byte(lang + "\n" + code))
+	cacheFile := path.Join("diagrams", lang, hex.EncodeToString(h[:])+".svg")
+	r.cacheLock.RLock()
+	bs, err := util.ReadFile(r.cacheFs, cacheFile)
+	r.cacheLock.RUnlock()
+	if err == nil {
+		return bs, nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	if v, ok := r.errors.Load(cacheFile); ok {
+		return nil, v.(error)
+	}
+
+	bs, err = r.renderers[lang](code)
+	if err != nil {
+		// 不可用时不缓存，渲染器可用之后即可生效
+		if !errors.Is(err, ErrDiagramUnavailable) {
+			r.errors.Store(cacheFile, err)
+		}
+		return nil, err
+	}
+	// 内联到页面时不需要 xml 声明
+	if bytes.HasPrefix(bs, []byte("<?xml")) {
+		if i := bytes.Index(bs, []byte("?>")); i != -1 {
+			bs = bytes.TrimSpace(bs[i+2:])
+		}
+	}
+
+	r.cacheLock.Lock()
+	err = writeCacheFile(r.cacheFs, cacheFile, bs)
+	r.cacheLock.Unlock()
+	if err != nil {
+		log.Warnf("cache diagram '%v' error: %v", cacheFile, err)
+	}
+	return bs, nil
+}
+
+// writeCacheFile 先写入临时文件再重命名，避免并发读取时读到写了一半的文件
+func writeCacheFile(fs billy.Filesystem, name string, body []byte) error {
+	tmp := fmt.Sprintf("%v.%v-%v.tmp", name, os.Getpid(), atomic.AddUint64(&diagramTmpSeq, 1))
+	if err := util.WriteFile(fs, tmp, body, 0644); err != nil {
+		return err
+	}
+	if err := fs.Rename(tmp, name); err != nil {
+		fs.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// rewrite 将已注册语言的代码块替换为 <div class="diagram diagram-{lang}"><svg>...</svg></div>，
+// 需要在代码高亮之前调用。
+func (r *diagramRenderer) rewrite(dom jsx.VDom) {
+	if r == nil || len(r.renderers) == 0 {
+		return
+	}
+	walkVDom(dom, func(d jsx.VDom) {
+		if d["nodeName"] != "pre" {
+			return
+		}
+		lang, _, code, ok := codeBlock(d)
+		if !ok || r.renderers[lang] == nil {
+			return
+		}
+		svg, err := r.render(lang, code)
+		if err != nil {
+			if errors.Is(err, ErrDiagramUnavailable) {
+				if _, warned := warnedDiagrams.LoadOrStore(lang, true); warned {
+					return
+				}
+			}
+			log.Warnf("render '%v' diagram error: %v", lang, err)
+			return
+		}
+		d["nodeName"] = "div"
+		d["attributes"] = map[string]interface{}{
+			"class":                   "diagram diagram-" + lang,
+			"dangerouslySetInnerHTML": map[string]interface{}{"__html": string(svg)},
+		}
+	})
+}
@@ -0,0 +1,77 @@
+package hollow
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestDiagram(t *testing.T) {
+	b, f := newTestSite(t, map[string]string{
+		"config.yml":      "theme: theme\nhighlight:\n  enable: true\n",
+		"contents/a.md":   "```box\na -> b\n```\n\n```d2\nx -> y\n```\n",
+		"contents/b.adoc": "[source,box]\n----\nc -> d\n----\n",
+		"theme/index.jsx": `
+import {getContents} from "@bysir/hollow"
+
+export default {
+  pages: [{path: "", component: () => <div>
+    {getContents("contents").list.map(c => <section dangerouslySetInnerHTML={{__html: c.content}}/>)}
+  </div>}],
+  assets: [],
+}
+`,
+	})
+	var rendered []string
+	b.RegisterDiagramRenderer("box", func(code string) ([]byte, error) {
+		rendered = append(rendered, code)
+		if strings.Contains(code, "bad") {
+			return nil, errors.New("syntax error")
+		}
+		return []byte(`<?xml version="1.0" encoding="UTF-8"?>` + "\n" + `<svg><text>` + strings.TrimSpace(code) + `</text></svg>`), nil
+	})
+
+	build := func() string {
+		return readTestFile(t, buildTestSite(t, b, ExecOption{}), "index.html")
+	}
+
+	body := build()
+	// 图形在代码高亮之前处理，不会被高亮
+	assert.Contains(t, body, `<section><div class="diagram diagram-box"><svg><text>a -> b</text></svg></div>`)
+	assert.Contains(t, body, `<section><div class="diagram diagram-box"><svg><text>c -> d</text></svg></div></section>`)
+	// d2 在进程内渲染，不需要安装 d2 命令
+	assert.Contains(t, body, `<div class="diagram diagram-d2"><svg`)
+	assert.Contains(t, body, `>x</text>`)
+	assert.Equal(t, []string{"a -> b\n", "c -> d"}, rendered)
+
+	// 没有变化的图形使用 CacheFs 中的缓存
+	writeTestFile(t, f, "contents/a.md", "# a\n\n```box\na -> b\n```\n\n```box\ne -> f\n```\n")
+	body = build()
+	assert.Contains(t, body, `<svg><text>e -> f</text></svg>`)
+	assert.Equal(t, []string{"a -> b\n", "c -> d", "e -> f\n"}, rendered)
+
+	// 缓存中没有残留的临时文件
+	fis, err := b.CacheFs.ReadDir("diagrams/box")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 3, len(fis))
+	for _, fi := range fis {
+		assert.True(t, strings.HasSuffix(fi.Name(), ".svg"), fi.Name())
+	}
+
+	// 渲染失败的图形保持原样，并且不会在每次构建时重新渲染
+	writeTestFile(t, f, "contents/a.md", "```box\nbad\n```\n")
+	body = build()
+	assert.Contains(t, body, `<span class="cl">bad`)
+	build()
+	assert.Equal(t, []string{"a -> b\n", "c -> d", "e -> f\n", "bad\n"}, rendered)
+
+	// 重新注册渲染器后会重新渲染
+	b.RegisterDiagramRenderer("box", func(code string) ([]byte, error) {
+		return []byte(`<svg>` + strings.TrimSpace(code) + `</svg>`), nil
+	})
+	body = build()
+	assert.Contains(t, body, `<div class="diagram diagram-box"><svg>bad</svg></div>`)
+}
@@ -0,0 +1,447 @@
+package hollow
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"golang.org/x/net/html"
+	htmlatom "golang.org/x/net/html/atom"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+)
+
+// ConfigFeed 声明一组订阅源，构建时会在 Path 目录下生成 feed.xml（RSS）、atom.xml 与 feed.json
+type ConfigFeed struct {
+	Source      string `json:"source" yaml:"source"` // 文章目录，与 getContents 的参数一致
+	Title       string `json:"title" yaml:"title"`
+	Description string `json:"description" yaml:"description"`
+	Sort        string `json:"sort" yaml:"sort"`   // 排序使用的 meta 字段，倒序排列，默认为 date
+	Limit       int    `json:"limit" yaml:"limit"` // 最多输出的文章数量，默认为 20
+	Path        string `json:"path" yaml:"path"`   // 输出目录，默认为根目录
+	Link        string `json:"link" yaml:"link"`   // 文章的页面路径，支持 :slug 与 :name 参数，默认为 :slug
+}
+
+const (
+	feedRssFile  = "feed.xml"
+	feedAtomFile = "atom.xml"
+	feedJsonFile = "feed.json"
+
+	feedDefaultLimit = 20
+)
+
+var feedContentTypes = map[string]string{
+	feedRssFile:  "application/rss+xml; charset=utf-8",
+	feedAtomFile: "application/atom+xml; charset=utf-8",
+	feedJsonFile: "application/feed+json; charset=utf-8",
+}
+
+type feedItem struct {
+	Url     string
+	Title   string
+	Summary string
+	Content string
+	Date    time.Time
+}
+
+// feedFile 是生成的订阅文件，Name 是相对于输出目录的路径
+type feedFile struct {
+	Name string
+	Body []byte
+}
+
+// metaString 返回 meta 中第一个不为空的字段
+func metaString(meta map[string]interface{}, keys ...string) string {
+	for _, k := range keys {
+		if v, ok := meta[k]; ok && v != nil {
+			if s := fmt.Sprintf("%v", v); s != "" {
+				return s
+			}
+		}
+	}
+	return ""
+}
+
+// summary 返回文章摘要：meta 中的 desc / summary，或者内容的摘要
+func summary(c Content) string {
+	if s := metaString(c.Meta, "desc", "summary", "description"); s != "" {
+		return s
+	}
+	return c.Excerpt
+}
+
+// lessMeta 比较两个 meta 值，能解析为日期的值按日期比较
+func lessMeta(a, b interface{}) bool {
+	ad, aok := parseDate(a)
+	bd, bok := parseDate(b)
+	if aok && bok {
+		return ad.Before(bd)
+	}
+	switch {
+	case a == nil:
+		return b != nil
+	case b == nil:
+		return false
+	}
+	return fmt.Sprintf("%v", a) < fmt.Sprintf("%v", b)
+}
+
+func (b *Hollow) feedItems(ctx *RenderContext, baseUrl string, f ConfigFeed) ([]feedItem, error) {
+	sortKey := f.Sort
+	if sortKey == "" {
+		sortKey = "date"
+	}
+	limit := f.Limit
+	if limit <= 0 {
+		limit = feedDefaultLimit
+	}
+	link := f.Link
+	if link == "" {
+		link = ":slug"
+	}
+
+	list := b.getContents(ctx)(f.Source, getBlogOption{
+		Sort: func(x, y interface{}) bool {
+			return lessMeta(y.(ContentTree).Meta[sortKey], x.(ContentTree).Meta[sortKey])
+		},
+		Size: limit,
+	})
+
+	items := make([]feedItem, 0, len(list.List))
+	for _, c := range list.List {
+		p, err := fillPathPattern(link, map[string]interface{}{
+			"name": c.Name,
+			"slug": firstNonEmpty(metaString(c.Meta, "slug"), c.Name),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("feed link of '%v' error: %w", c.Name, err)
+		}
+		// 与页面的输出路径保持一致，posts/a => posts/a/
+		if path.Ext(p) == "" {
+			p += "/"
+		}
+		date, _ := parseDate(c.Meta["date"])
+		u := pageUrl(baseUrl, p)
+
+		items = append(items, feedItem{
+			Url:     u,
+			Title:   firstNonEmpty(metaString(c.Meta, "title"), c.Name),
+			Summary: summary(c.Content),
+			Content: absoluteUrls(c.Content.Content, u),
+			Date:    date,
+		})
+	}
+	return items, nil
+}
+
+// absoluteUrls 将 html 中相对的 src、href 与 srcset 转为基于 base（文章地址）的绝对地址，
+// 阅读器中没有站点的上下文，无法解析 /__source/... 这样的地址。没有需要转换的地址时原样返回。
+func absoluteUrls(content string, base string) string {
+	baseUrl, err := url.Parse(base)
+	if err != nil {
+		return content
+	}
+	nodes, err := html.ParseFragment(strings.NewReader(content), &html.Node{Type: html.ElementNode, Data: "body", DataAtom: htmlatom.Body})
+	if err != nil {
+		return content
+	}
+
+	resolve := func(s string) string {
+		u, err := url.Parse(strings.TrimSpace(s))
+		if err != nil || u.IsAbs() {
+			return s
+		}
+		return baseUrl.ResolveReference(u).String()
+	}
+	changed := false
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		for i, a := range n.Attr {
+			var v string
+			switch a.Key {
+			case "src", "href", "poster":
+				v = resolve(a.Val)
+			case "srcset":
+				// srcset 为 "url 描述, url 描述"
+				cs := strings.Split(a.Val, ",")
+				for j, c := range cs {
+					fs := strings.Fields(c)
+					if len(fs) != 0 {
+						fs[0] = resolve(fs[0])
+					}
+					cs[j] = strings.Join(fs, " ")
+				}
+				v = strings.Join(cs, ", ")
+			default:
+				continue
+			}
+			if v != a.Val {
+				n.Attr[i].Val = v
+				changed = true
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	for _, n := range nodes {
+		walk(n)
+	}
+	if !changed {
+		return content
+	}
+
+	var buf bytes.Buffer
+	for _, n := range nodes {
+		if err = html.Render(&buf, n); err != nil {
+			return content
+		}
+	}
+	return buf.String()
+}
+
+func firstNonEmpty(s ...string) string {
+	for _, i := range s {
+		if i != "" {
+			return i
+		}
+	}
+	return ""
+}
+
+// buildFeeds 根据配置生成所有订阅文件
+func (b *Hollow) buildFeeds(ctx *RenderContext, conf HollowConfig) ([]feedFile, error) {
+	if len(conf.Feeds) == 0 {
+		return nil, nil
+	}
+	if conf.BaseUrl == "" {
+		return nil, fmt.Errorf("feeds requires 'base_url' in config")
+	}
+
+	var files []feedFile
+	for _, f := range conf.Feeds {
+		items, err := b.feedItems(ctx, conf.BaseUrl, f)
+		if err != nil {
+			return nil, err
+		}
+		title := firstNonEmpty(f.Title, conf.BaseUrl)
+		dir := strings.Trim(f.Path, "/")
+		home := pageUrl(conf.BaseUrl, dir)
+		if dir != "" {
+			home += "/"
+		}
+
+		for _, name := range []string{feedRssFile, feedAtomFile, feedJsonFile} {
+			self := path.Join(dir, name)
+			var body []byte
+			switch name {
+			case feedRssFile:
+				body, err = renderRss(title, f.Description, home, items)
+			case feedAtomFile:
+				body, err = renderAtom(title, f.Description, home, pageUrl(conf.BaseUrl, self), items)
+			case feedJsonFile:
+				body, err = renderJsonFeed(title, f.Description, home, pageUrl(conf.BaseUrl, self), items)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("render feed '%v' error: %w", self, err)
+			}
+			files = append(files, feedFile{Name: self, Body: body})
+		}
+	}
+	return files, nil
+}
+
+// lastUpdated 返回最新的文章时间，没有时返回当前时间
+func lastUpdated(items []feedItem) time.Time {
+	var t time.Time
+	for _, i := range items {
+		if i.Date.After(t) {
+			t = i.Date
+		}
+	}
+	if t.IsZero() {
+		return time.Now()
+	}
+	return t
+}
+
+func encodeXml(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	enc := xml.NewEncoder(&buf)
+	enc.Indent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+type rssItem struct {
+	Title       string `xml:"title"`
+	Link        string `xml:"link"`
+	Guid        string `xml:"guid"`
+	PubDate     string `xml:"pubDate,omitempty"`
+	Description string `xml:"description"`
+	Content     string `xml:"content:encoded"`
+}
+
+type rss struct {
+	XMLName      xml.Name `xml:"rss"`
+	Version      string   `xml:"version,attr"`
+	XmlnsContent string   `xml:"xmlns:content,attr"`
+	Channel      struct {
+		Title         string    `xml:"title"`
+		Link          string    `xml:"link"`
+		Description   string    `xml:"description"`
+		LastBuildDate string    `xml:"lastBuildDate"`
+		Items         []rssItem `xml:"item"`
+	} `xml:"channel"`
+}
+
+func renderRss(title, desc, home string, items []feedItem) ([]byte, error) {
+	r := rss{Version: "2.0", XmlnsContent: "http://purl.org/rss/1.0/modules/content/"}
+	r.Channel.Title = title
+	r.Channel.Link = home
+	r.Channel.Description = desc
+	r.Channel.LastBuildDate = lastUpdated(items).Format(time.RFC1123Z)
+	for _, i := range items {
+		ri := rssItem{
+			Title:       i.Title,
+			Link:        i.Url,
+			Guid:        i.Url,
+			Description: i.Summary,
+			Content:     i.Content,
+		}
+		if !i.Date.IsZero() {
+			ri.PubDate = i.Date.Format(time.RFC1123Z)
+		}
+		r.Channel.Items = append(r.Channel.Items, ri)
+	}
+	return encodeXml(r)
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+}
+
+type atomText struct {
+	Type string `xml:"type,attr,omitempty"`
+	Body string `xml:",chardata"`
+}
+
+type atomEntry struct {
+	Title   string   `xml:"title"`
+	Link    atomLink `xml:"link"`
+	Id      string   `xml:"id"`
+	Updated string   `xml:"updated"`
+	Summary string   `xml:"summary"`
+	Content atomText `xml:"content"`
+}
+
+type atom struct {
+	XMLName  xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Title    string      `xml:"title"`
+	Subtitle string      `xml:"subtitle,omitempty"`
+	Links    []atomLink  `xml:"link"`
+	Id       string      `xml:"id"`
+	Updated  string      `xml:"updated"`
+	Entries  []atomEntry `xml:"entry"`
+}
+
+func renderAtom(title, desc, home, self string, items []feedItem) ([]byte, error) {
+	updated := lastUpdated(items)
+	a := atom{
+		Title:    title,
+		Subtitle: desc,
+		Links:    []atomLink{{Href: home}, {Href: self, Rel: "self"}},
+		Id:       home,
+		Updated:  updated.Format(time.RFC3339),
+	}
+	for _, i := range items {
+		d := i.Date
+		if d.IsZero() {
+			d = updated
+		}
+		a.Entries = append(a.Entries, atomEntry{
+			Title:   i.Title,
+			Link:    atomLink{Href: i.Url},
+			Id:      i.Url,
+			Updated: d.Format(time.RFC3339),
+			Summary: i.Summary,
+			Content: atomText{Type: "html", Body: i.Content},
+		})
+	}
+	return encodeXml(a)
+}
+
+type jsonFeedItem struct {
+	Id            string `json:"id"`
+	Url           string `json:"url"`
+	Title         string `json:"title"`
+	Summary       string `json:"summary,omitempty"`
+	ContentHtml   string `json:"content_html"`
+	DatePublished string `json:"date_published,omitempty"`
+}
+
+type jsonFeed struct {
+	Version     string         `json:"version"`
+	Title       string         `json:"title"`
+	Description string         `json:"description,omitempty"`
+	HomePageUrl string         `json:"home_page_url"`
+	FeedUrl     string         `json:"feed_url"`
+	Items       []jsonFeedItem `json:"items"`
+}
+
+func renderJsonFeed(title, desc, home, self string, items []feedItem) ([]byte, error) {
+	f := jsonFeed{
+		Version:     "https://jsonfeed.org/version/1.1",
+		Title:       title,
+		Description: desc,
+		HomePageUrl: home,
+		FeedUrl:     self,
+		Items:       []jsonFeedItem{},
+	}
+	for _, i := range items {
+		ji := jsonFeedItem{
+			Id:          i.Url,
+			Url:         i.Url,
+			Title:       i.Title,
+			Summary:     i.Summary,
+			ContentHtml: i.Content,
+		}
+		if !i.Date.IsZero() {
+			ji.DatePublished = i.Date.Format(time.RFC3339)
+		}
+		f.Items = append(f.Items, ji)
+	}
+	return json.MarshalIndent(f, "", "  ")
+}
+
+// matchFeed 返回路径为 reqPath 的订阅文件，用于 dev server
+func (b *Hollow) matchFeed(ctx *RenderContext, conf HollowConfig, reqPath string) (feedFile, bool, error) {
+	if _, ok := feedContentTypes[path.Base(reqPath)]; !ok {
+		return feedFile{}, false, nil
+	}
+	files, err := b.buildFeeds(ctx, conf)
+	if err != nil {
+		return feedFile{}, false, err
+	}
+	for _, f := range files {
+		if f.Name == reqPath {
+			return f, true, nil
+		}
+	}
+	return feedFile{}, false, nil
+}
+
+func hasFile(files []string, name string) bool {
+	for _, f := range files {
+		if f == name {
+			return true
+		}
+	}
+	return false
+}
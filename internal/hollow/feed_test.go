@@ -0,0 +1,106 @@
+package hollow
+
+import (
+	"encoding/json"
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/go-git/go-billy/v5/util"
+	"github.com/stretchr/testify/assert"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestFeeds(t *testing.T) {
+	source := memfs.New()
+	writeTestFile(t, source, "config.yml", `
+theme: theme
+base_url: https://example.com
+feeds:
+  - source: contents
+    title: Blog
+    description: my blog
+    limit: 2
+    link: posts/:slug
+`)
+	writeTestFile(t, source, "contents/a.md", "---\ntitle: A & B\ndate: 2022-01-01\n---\nhello **a**\n\n![x](./img.png) [b](/posts/bb/) [c](https://c.com) [d](#d)")
+	writeTestFile(t, source, "contents/b.md", "---\ntitle: B\ndate: 2022-03-01\nslug: bb\ndesc: about b\n---\nhello b")
+	writeTestFile(t, source, "contents/c.md", "---\ntitle: C\ndate: 2021-01-01\n---\nhello c")
+	writeTestFile(t, source, "theme/index.jsx", `
+export default {
+  pages: [{path: "", component: () => <div>home</div>}],
+  assets: [],
+}
+`)
+
+	b, err := NewHollow(Option{SourceFs: source})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dst := memfs.New()
+	err = b.BuildToFs(NewRenderContext(), dst, ExecOption{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bs, err := util.ReadFile(dst, "feed.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var f jsonFeed
+	if err = json.Unmarshal(bs, &f); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "Blog", f.Title)
+	assert.Equal(t, "https://example.com/feed.json", f.FeedUrl)
+	if assert.Len(t, f.Items, 2) {
+		assert.Equal(t, jsonFeedItem{
+			Id:            "https://example.com/posts/bb/",
+			Url:           "https://example.com/posts/bb/",
+			Title:         "B",
+			Summary:       "about b",
+			ContentHtml:   "<p>hello b</p>",
+			DatePublished: "2022-03-01T00:00:00Z",
+		}, f.Items[0])
+		assert.Equal(t, "A & B", f.Items[1].Title)
+		assert.Equal(t, "hello a b c d", f.Items[1].Summary)
+		// 相对地址转为绝对地址
+		assert.Equal(t, `<p>hello <strong>a</strong></p><p><img alt="x" src="https://example.com/__source/contents/img.png"/> <a href="https://example.com/posts/bb/">b</a> <a href="https://c.com">c</a> <a href="https://example.com/posts/a/#d">d</a></p>`, f.Items[1].ContentHtml)
+	}
+
+	rss, err := util.ReadFile(dst, "feed.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Contains(t, string(rss), "<title>A &amp; B</title>")
+	assert.Contains(t, string(rss), "<content:encoded>&lt;p&gt;hello b&lt;/p&gt;</content:encoded>")
+	assert.Contains(t, string(rss), "<pubDate>Tue, 01 Mar 2022 00:00:00 +0000</pubDate>")
+
+	atom, err := util.ReadFile(dst, "atom.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Contains(t, string(atom), `<feed xmlns="http://www.w3.org/2005/Atom">`)
+	assert.Contains(t, string(atom), `<link href="https://example.com/atom.xml" rel="self"></link>`)
+	assert.Contains(t, string(atom), "<updated>2022-03-01T00:00:00Z</updated>")
+
+	handle := b.ServiceHandle(ExecOption{IsDev: true})
+	w := httptest.NewRecorder()
+	handle(w, httptest.NewRequest("GET", "/feed.json", nil))
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, "application/feed+json; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, string(bs), w.Body.String())
+
+	// 并发请求各自使用自己的配置，使用 go test -race 检查
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w := httptest.NewRecorder()
+			handle(w, httptest.NewRequest("GET", "/feed.xml", nil))
+			assert.Equal(t, 200, w.Code)
+		}()
+	}
+	wg.Wait()
+}
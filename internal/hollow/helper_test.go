@@ -0,0 +1,62 @@
+package hollow
+
+import (
+	"github.com/go-git/go-billy/v5"
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/go-git/go-billy/v5/util"
+	"testing"
+)
+
+func writeTestFile(t *testing.T, f billy.Filesystem, name string, body string) {
+	file, err := f.Create(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	_, err = file.Write([]byte(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// writeTestFiles 写入多个文件，key 为文件路径
+func writeTestFiles(t *testing.T, f billy.Filesystem, files map[string]string) {
+	for name, body := range files {
+		writeTestFile(t, f, name, body)
+	}
+}
+
+func readTestFile(t *testing.T, f billy.Filesystem, name string) string {
+	bs, err := util.ReadFile(f, name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(bs)
+}
+
+// newTestSite 使用内存中的 files 创建 Hollow，返回的 source 可以用于修改文件
+func newTestSite(t *testing.T, files map[string]string) (b *Hollow, source billy.Filesystem) {
+	source = memfs.New()
+	writeTestFiles(t, source, files)
+	b, err := NewHollow(Option{SourceFs: source})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b, source
+}
+
+// buildTestSite 将站点构建到新的内存文件系统中
+func buildTestSite(t *testing.T, b *Hollow, o ExecOption) billy.Filesystem {
+	dst := memfs.New()
+	err := b.BuildToFs(NewRenderContext(), dst, o)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dst
+}
+
+// buildSite 创建站点并构建一次，返回首页的内容
+func buildSite(t *testing.T, files map[string]string, o ExecOption) string {
+	b, _ := newTestSite(t, files)
+	return readTestFile(t, buildTestSite(t, b, o), "index.html")
+}
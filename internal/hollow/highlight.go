@@ -0,0 +1,303 @@
+package hollow
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/alecthomas/chroma/v2"
+	chromahtml "github.com/alecthomas/chroma/v2/formatters/html"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/alecthomas/chroma/v2/styles"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/renderer/html"
+	"github.com/yuin/goldmark/util"
+	jsx "github.com/zbysir/gojsx"
+	"github.com/zbysir/hollow/internal/pkg/log"
+	stdhtml "html"
+	"os"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ConfigHighlight 配置构建时的代码高亮，开启后主题不再需要在浏览器中高亮代码
+type ConfigHighlight struct {
+	Enable      bool   `json:"enable" yaml:"enable"`
+	Style       string `json:"style" yaml:"style"`               // chroma 的样式名称，默认为 github
+	Inline      bool   `json:"inline" yaml:"inline"`             // 使用行内样式代替 class，不需要引入样式文件
+	LineNumbers bool   `json:"line_numbers" yaml:"line_numbers"` // 为所有代码块显示行号
+	Stylesheet  string `json:"stylesheet" yaml:"stylesheet"`     // class 模式下生成的样式文件，默认为 highlight.css
+}
+
+func (c ConfigHighlight) style() *chroma.Style {
+	name := c.Style
+	if name == "" {
+		name = "github"
+	}
+	s, ok := styles.Registry[strings.ToLower(name)]
+	if !ok {
+		log.Warnf("Unknown highlight style '%v', use github instead", name)
+		return styles.Get("github")
+	}
+	return s
+}
+
+func (c ConfigHighlight) stylesheet() string {
+	if c.Stylesheet == "" {
+		return "highlight.css"
+	}
+	return strings.Trim(c.Stylesheet, "/")
+}
+
+// hasStylesheet 返回是否需要生成样式文件，行内样式不需要
+func (c ConfigHighlight) hasStylesheet() bool {
+	return c.Enable && !c.Inline
+}
+
+// css 返回 class 模式下的样式
+func (c ConfigHighlight) css() ([]byte, error) {
+	var buf bytes.Buffer
+	err := chromahtml.New(chromahtml.WithClasses(true)).WriteCSS(&buf, c.style())
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// markdownFeatures 是渲染 md 与 mdx 时可以开启的功能
+type markdownFeatures struct {
+	math     bool // 解析 $...$ 公式
+	codeMeta bool // 在代码块的 data-meta 中保留语言之后的信息，用于代码高亮
+}
+
+// markdownOptions 是渲染 md 与 mdx 时额外的 goldmark 配置
+func markdownOptions(f markdownFeatures) []goldmark.Option {
+	var os []goldmark.Option
+	if f.codeMeta {
+		os = append(os, goldmark.WithRendererOptions(renderer.WithNodeRenderers(
+			util.Prioritized(&codeBlockRenderer{Config: html.NewConfig()}, 100),
+		)))
+	}
+	if f.math {
+		os = append(os, goldmark.WithExtensions(&mathExtension{}))
+	}
+	return os
+}
+
+// codeBlockRenderer 与 goldmark 默认的代码块渲染一致，但会在 data-meta 中保留语言之后的信息，如 ```go {3-5} 中的 {3-5}
+type codeBlockRenderer struct {
+	html.Config
+}
+
+func (r *codeBlockRenderer) SetOption(name renderer.OptionName, value interface{}) {
+	r.Config.SetOption(name, value)
+}
+
+func (r *codeBlockRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(ast.KindFencedCodeBlock, r.renderFencedCodeBlock)
+}
+
+func (r *codeBlockRenderer) renderFencedCodeBlock(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	n := node.(*ast.FencedCodeBlock)
+	if !entering {
+		_, _ = w.WriteString("</code></pre>\n")
+		return ast.WalkContinue, nil
+	}
+
+	_, _ = w.WriteString("<pre><code")
+	if language := n.Language(source); language != nil {
+		_, _ = w.WriteString(` class="language-`)
+		r.Writer.Write(w, language)
+		_ = w.WriteByte('"')
+
+		info := n.Info.Segment.Value(source)
+		if meta := bytes.TrimSpace(info[bytes.Index(info, language)+len(language):]); len(meta) != 0 {
+			_, _ = w.WriteString(` data-meta="`)
+			r.Writer.Write(w, meta)
+			_ = w.WriteByte('"')
+		}
+	}
+	_ = w.WriteByte('>')
+	for i := 0; i < n.Lines().Len(); i++ {
+		line := n.Lines().At(i)
+		r.Writer.RawWrite(w, line.Value(source))
+	}
+	return ast.WalkContinue, nil
+}
+
+// codeHighlighter 高亮内容中的代码块
+type codeHighlighter struct {
+	conf  ConfigHighlight
+	style *chroma.Style
+}
+
+// newCodeHighlighter 没有开启代码高亮时返回 nil
+func newCodeHighlighter(conf ConfigHighlight) *codeHighlighter {
+	if !conf.Enable {
+		return nil
+	}
+	return &codeHighlighter{conf: conf, style: conf.style()}
+}
+
+var codeHtmlReg = regexp.MustCompile(`^<code(?: class="language-([^"]*)")?(?: data-meta="([^"]*)")?>([\s\S]*)</code>$`)
+
+// codeBlock 返回 pre 节点中的代码，支持 md 生成的 dangerouslySetInnerHTML 与 <pre><code> 两种结构
+func codeBlock(d jsx.VDom) (lang, meta, code string, ok bool) {
+	attr, _ := d["attributes"].(map[string]interface{})
+	if inner, _ := lookupMapI(attr, "dangerouslySetInnerHTML", "__html"); inner != nil {
+		s, _ := inner.(string)
+		m := codeHtmlReg.FindStringSubmatch(s)
+		if m == nil {
+			return "", "", "", false
+		}
+		return stdhtml.UnescapeString(m[1]), stdhtml.UnescapeString(m[2]), stdhtml.UnescapeString(m[3]), true
+	}
+
+	children, _ := attr["children"].([]interface{})
+	if len(children) != 1 {
+		return "", "", "", false
+	}
+	var c jsx.VDom
+	switch t := children[0].(type) {
+	case jsx.VDom:
+		c = t
+	case map[string]interface{}:
+		c = t
+	}
+	if c == nil || c["nodeName"] != "code" {
+		return "", "", "", false
+	}
+	cattr, _ := c["attributes"].(map[string]interface{})
+	class, _ := cattr["class"].(string)
+	meta, _ = cattr["data-meta"].(string)
+	var sb strings.Builder
+	switch t := cattr["children"].(type) {
+	case string:
+		sb.WriteString(t)
+	case []interface{}:
+		for _, i := range t {
+			s, ok := i.(string)
+			if !ok {
+				return "", "", "", false
+			}
+			sb.WriteString(s)
+		}
+	}
+	return strings.TrimPrefix(class, "language-"), meta, sb.String(), true
+}
+
+// parseCodeMeta 解析代码块语言之后的信息：{1,3-5} 为高亮的行，showLineNumbers 显示行号
+func parseCodeMeta(meta string) (ranges [][2]int, lineNumbers bool) {
+	for _, f := range strings.Fields(meta) {
+		if f == "showLineNumbers" {
+			lineNumbers = true
+			continue
+		}
+		if !strings.HasPrefix(f, "{") || !strings.HasSuffix(f, "}") {
+			continue
+		}
+		for _, r := range strings.Split(strings.Trim(f, "{}"), ",") {
+			ss := strings.SplitN(strings.TrimSpace(r), "-", 2)
+			start, err := strconv.Atoi(ss[0])
+			if err != nil {
+				continue
+			}
+			end := start
+			if len(ss) == 2 {
+				if end, err = strconv.Atoi(ss[1]); err != nil || end < start {
+					continue
+				}
+			}
+			ranges = append(ranges, [2]int{start, end})
+		}
+	}
+	return
+}
+
+// highlight 返回高亮之后的 html
+func (h *codeHighlighter) highlight(lang, meta, code string) (string, error) {
+	lexer := lexers.Get(lang)
+	if lexer == nil {
+		lexer = lexers.Fallback
+	}
+	it, err := chroma.Coalesce(lexer).Tokenise(nil, code)
+	if err != nil {
+		return "", err
+	}
+
+	ranges, lineNumbers := parseCodeMeta(meta)
+	f := chromahtml.New(
+		chromahtml.WithClasses(!h.conf.Inline),
+		chromahtml.WithLineNumbers(h.conf.LineNumbers || lineNumbers),
+		chromahtml.HighlightLines(ranges),
+	)
+	var buf bytes.Buffer
+	if err = f.Format(&buf, h.style, it); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// rewrite 将代码块替换为高亮之后的 <div class="highlight"><pre class="chroma">...</pre></div>
+func (h *codeHighlighter) rewrite(dom jsx.VDom) {
+	if h == nil {
+		return
+	}
+	walkVDom(dom, func(d jsx.VDom) {
+		if d["nodeName"] != "pre" {
+			return
+		}
+		lang, meta, code, ok := codeBlock(d)
+		if !ok {
+			return
+		}
+		s, err := h.highlight(lang, meta, code)
+		if err != nil {
+			log.Warnf("highlight '%v' code error: %v", lang, err)
+			return
+		}
+		d["nodeName"] = "div"
+		d["attributes"] = map[string]interface{}{
+			"class":                   "highlight",
+			"dangerouslySetInnerHTML": map[string]interface{}{"__html": s},
+		}
+	})
+}
+
+// highlighter 返回当前配置的代码高亮，没有开启时返回 nil
+func (b *Hollow) highlighter(ctx *RenderContext) *codeHighlighter {
+	c, err := b.LoadConfig(ctx)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		log.Warnf("LoadConfig for highlight error: %v", err)
+	}
+	return newCodeHighlighter(c.Hollow.Highlight)
+}
+
+// matchHighlightCss 在开发服务中返回代码高亮的样式文件
+func matchHighlightCss(conf ConfigHighlight, reqPath string) ([]byte, bool, error) {
+	if !conf.hasStylesheet() || reqPath != conf.stylesheet() {
+		return nil, false, nil
+	}
+	css, err := conf.css()
+	if err != nil {
+		return nil, false, fmt.Errorf("highlight css error: %w", err)
+	}
+	return css, true, nil
+}
+
+// getHighlightCssUrl 返回代码高亮样式文件的地址，如 /highlight.css，没有开启或使用行内样式时返回空字符串
+func (b *Hollow) getHighlightCssUrl(ctx *RenderContext) func() string {
+	return func() string {
+		c, err := b.LoadConfig(ctx)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			log.Warnf("LoadConfig for getHighlightCssUrl error: %v", err)
+		}
+		if !c.Hollow.Highlight.hasStylesheet() {
+			return ""
+		}
+		return "/" + path.Clean(c.Hollow.Highlight.stylesheet())
+	}
+}
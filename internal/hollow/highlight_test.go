@@ -0,0 +1,73 @@
+package hollow
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParseCodeMeta(t *testing.T) {
+	ranges, lineNumbers := parseCodeMeta("{1,3-5} showLineNumbers")
+	assert.Equal(t, [][2]int{{1, 1}, {3, 5}}, ranges)
+	assert.True(t, lineNumbers)
+
+	ranges, lineNumbers = parseCodeMeta(`title="a.go" {x,5-3,7}`)
+	assert.Equal(t, [][2]int{{7, 7}}, ranges)
+	assert.False(t, lineNumbers)
+}
+
+func TestHighlight(t *testing.T) {
+	b, f := newTestSite(t, map[string]string{
+		"config.yml":      "theme: theme\nhighlight:\n  enable: true\n",
+		"contents/a.md":   "```go {2}\npackage main\nfunc main() {}\n```\n",
+		"contents/b.mdx":  "```js showLineNumbers\nconst a = {b: 1}\n```\n",
+		"contents/c.adoc": "[source,go]\n----\npackage main\n----\n",
+		"theme/index.jsx": `
+import {getContents, getHighlightCssUrl} from "@bysir/hollow"
+
+export default {
+  pages: [{path: "", component: () => <div>
+    <link rel="stylesheet" href={getHighlightCssUrl()}/>
+    {getContents("contents").list.map(c => <section dangerouslySetInnerHTML={{__html: c.content}}/>)}
+  </div>}],
+  assets: [],
+}
+`,
+	})
+	dst := buildTestSite(t, b, ExecOption{})
+	body := readTestFile(t, dst, "index.html")
+	assert.Contains(t, body, `<link href="/highlight.css" rel="stylesheet"/>`)
+	// md 中高亮第 2 行
+	assert.Contains(t, body, `<section><div class="highlight"><pre tabindex="0" class="chroma"><code><span class="line"><span class="cl"><span class="kn">package</span> <span class="nx">main</span>
+</span></span><span class="line hl"><span class="cl"><span class="kd">func</span>`)
+	// mdx 中的 {} 不会被当作表达式，并显示行号
+	assert.Contains(t, body, `<span class="line"><span class="ln">1</span><span class="cl"><span class="kr">const</span> <span class="nx">a</span> <span class="o">=</span> <span class="p">{</span>`)
+	assert.Contains(t, body, `<section><div class="highlight"><pre tabindex="0" class="chroma"><code><span class="line"><span class="cl"><span class="kn">package</span> <span class="nx">main</span></span></span></code></pre></div></section>`)
+
+	css := readTestFile(t, dst, "highlight.css")
+	assert.Contains(t, css, `.chroma .hl {`)
+
+	handle := b.ServiceHandle(ExecOption{IsDev: true})
+	w := httptest.NewRecorder()
+	handle(w, httptest.NewRequest("GET", "/highlight.css", nil))
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, "text/css; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, css, w.Body.String())
+
+	// 行内样式不需要样式文件
+	writeTestFile(t, f, "config.yml", "theme: theme\nhighlight:\n  enable: true\n  inline: true\n  style: monokai\n")
+	dst = buildTestSite(t, b, ExecOption{})
+	body = readTestFile(t, dst, "index.html")
+	assert.Contains(t, body, `<link href="" rel="stylesheet"/>`)
+	assert.Contains(t, body, `<pre tabindex="0" style="color:#f8f8f2;background-color:#272822;">`)
+	_, err := dst.Stat("highlight.css")
+	assert.Error(t, err)
+
+	// 没有开启时代码块与默认渲染一致，不会添加 data-meta
+	writeTestFile(t, f, "config.yml", "theme: theme\n")
+	body = readTestFile(t, buildTestSite(t, b, ExecOption{}), "index.html")
+	assert.Contains(t, body, `<section><pre><code class="language-go">package main
+func main() {}
+</code></pre></section>`)
+	assert.NotContains(t, body, `data-meta`)
+}
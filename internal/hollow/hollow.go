@@ -26,6 +26,7 @@ import (
 	"github.com/zbysir/hollow/internal/pkg/httpsrv"
 	"github.com/zbysir/hollow/internal/pkg/log"
 	"github.com/zbysir/hollow/internal/pkg/timetrack"
+	"github.com/zbysir/hollow/internal/pkg/util"
 	"github.com/zbysir/hollow/internal/pkg/ws"
 	"go.uber.org/zap"
 	"gopkg.in/yaml.v3"
@@ -71,14 +72,132 @@ func tryToVDom(i interface{}) gojsx.VDom {
 	return gojsx.VDom{}
 }
 
+// distFile 返回页面的输出文件，有扩展名时为文件，否则存入文件夹，如 posts/a/index.html
+func (p Page) distFile() string {
+	name := p.GetPath()
+	if filepath.Ext(name) != "" {
+		return name
+	}
+	return filepath.Join(name, "index.html")
+}
+
+// IsDynamic 返回是否是动态路由页面，如 posts/:slug
+func (p Page) IsDynamic() bool {
+	for _, s := range strings.Split(p.GetPath(), "/") {
+		if strings.HasPrefix(s, ":") {
+			return true
+		}
+	}
+	return false
+}
+
+// getPaths 调用动态页面的 getPaths 函数，返回所有路由参数，如 [{slug: 'a'}, {slug: 'b'}]
+func (p Page) getPaths() ([]map[string]interface{}, error) {
+	t, ok := p["getPaths"].(*goja.Object)
+	if !ok {
+		return nil, fmt.Errorf("dynamic page must have 'getPaths' function")
+	}
+	c, ok := gojsx.AssertFunction(t)
+	if !ok {
+		return nil, fmt.Errorf("'getPaths' must be a function")
+	}
+	val, err := c(goja.Null())
+	if err != nil {
+		return nil, err
+	}
+
+	list, ok := val.Export().([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("'getPaths' must return an array, actual %T", val.Export())
+	}
+	ps := make([]map[string]interface{}, len(list))
+	for i, v := range list {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("'getPaths' must return an array of object, actual item %T", v)
+		}
+		ps[i] = m
+	}
+	return ps, nil
+}
+
+// withParams 返回填充了路由参数的页面，参数会传递给 component 函数
+func (p Page) withParams(vm *goja.Runtime, path string, params map[string]interface{}) Page {
+	n := make(Page, len(p)+1)
+	for k, v := range p {
+		n[k] = v
+	}
+	n["path"] = path
+	n["params"] = vm.ToValue(params)
+	return n
+}
+
+// paramsHash 返回动态页面参数（getPaths 返回的对象）的 hash，静态页面返回空字符串。
+// 参数中可以包含 getPaths 读取的内容，参数变化时页面需要重新渲染。
+func (p Page) paramsHash() string {
+	v, ok := p["params"].(goja.Value)
+	if !ok {
+		return ""
+	}
+	bs, err := json.Marshal(v.Export())
+	if err != nil {
+		// 无法计算时每次都重新渲染
+		return fmt.Sprintf("unhashable-%v", time.Now().UnixNano())
+	}
+	return util.MD5(string(bs))
+}
+
+// matchPathPattern 使用 pattern（如 posts/:slug）匹配 path，返回路由参数
+func matchPathPattern(pattern string, path string) (map[string]interface{}, bool) {
+	ps := strings.Split(strings.Trim(pattern, "/"), "/")
+	ss := strings.Split(strings.Trim(path, "/"), "/")
+	if len(ps) != len(ss) {
+		return nil, false
+	}
+
+	params := map[string]interface{}{}
+	for i, p := range ps {
+		if strings.HasPrefix(p, ":") {
+			if ss[i] == "" {
+				return nil, false
+			}
+			params[strings.TrimPrefix(p, ":")] = ss[i]
+		} else if p != ss[i] {
+			return nil, false
+		}
+	}
+	return params, true
+}
+
+// fillPathPattern 使用路由参数填充 pattern，如 posts/:slug => posts/hello
+func fillPathPattern(pattern string, params map[string]interface{}) (string, error) {
+	ps := strings.Split(strings.Trim(pattern, "/"), "/")
+	for i, p := range ps {
+		if strings.HasPrefix(p, ":") {
+			name := strings.TrimPrefix(p, ":")
+			v, ok := params[name]
+			if !ok || v == nil {
+				return "", fmt.Errorf("missing param '%v' in %v", name, params)
+			}
+			ps[i] = fmt.Sprintf("%v", v)
+		}
+	}
+	return strings.Join(ps, "/"), nil
+}
+
 func (p Page) GetComponent() (gojsx.VDom, error) {
 	var v gojsx.VDom
 	switch t := p["component"].(type) {
 	case *goja.Object:
 		c, ok := gojsx.AssertFunction(t)
 		if ok {
+			var args []goja.Value
+			if params, ok := p["params"].(goja.Value); ok {
+				// for: component: (params) => Post(params)
+				args = append(args, params)
+			}
 			// for: component: () => Index(props)
-			val, err := c(goja.Null())
+			val, err := c(goja.Null(), args...)
 			if err != nil {
 				return v, err
 			}
@@ -125,6 +244,19 @@ type Hollow struct {
 	// gojsx 使用了 fs 地址作为缓存 key，这里也缓存上 ThemeLoader 固定 fs 对象地址。
 	themeLoaderCache *lru.Cache[string, ThemeLoader]
 
+	contentLoaders    map[string]ContentLoaderFactory // 内容加载器，key 为扩展名
+	contentLoaderLock sync.RWMutex
+
+	diagramRenderers map[string]DiagramRenderer // 图形渲染器，key 为代码块的语言
+	diagramErrors    *sync.Map                  // 渲染失败的图形，见 diagramRenderer.errors
+	diagramLock      sync.RWMutex
+	cacheLock        sync.RWMutex // CacheFs 默认为不支持并发的 memfs，并发读写图片与图形的缓存时需要加锁
+	searchCache      searchCache  // 开发服务中生成的搜索索引
+	searchLock       sync.Mutex
+
+	markdownJsxs    map[markdownFeatures]*gojsx.Jsx // 开启 math 或 highlight 时渲染 md 与 mdx 使用的 jsx，第一次使用时创建
+	markdownJsxLock sync.Mutex
+
 	Option
 }
 
@@ -134,6 +266,18 @@ type RenderContext struct {
 	debug bool
 	data  sync.Map
 	lock  sync.Mutex
+	deps  map[string]struct{} // 渲染过程中读取的 source 文件，用于增量构建
+	// outputs 渲染过程中产生的页面之外的文件，如复制到 __source 下的图片与生成的图片版本，用于增量构建时删除不再生成的文件
+	outputs map[string]struct{}
+
+	showDrafts   bool                     // 显示草稿、定时发布与已过期的内容
+	themeLoaders map[string]ContentLoader // 主题中导出的内容加载器，key 为扩展名，只能在执行该主题的协程中使用
+	themeFs      fs.FS                    // 主题文件，image 函数可以处理主题中的图片
+
+	themeLoading bool                // 正在执行主题模块，主题的加载器还没有安装
+	loadingExts  map[string]struct{} // 执行主题模块时读取的内容的扩展名
+
+	fileDates *fileDates // git 提交历史中文件的时间，fork 出的 RenderContext 共享同一份
 }
 
 func (b *RenderContext) timerStart(span string) func() {
@@ -166,6 +310,104 @@ func (b *RenderContext) Save(k string, v interface{}) {
 	}
 }
 
+// dependOn 记录渲染依赖的 source 文件（或文件夹）
+func (b *RenderContext) dependOn(files ...string) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if b.deps == nil {
+		b.deps = map[string]struct{}{}
+	}
+	for _, f := range files {
+		b.deps[f] = struct{}{}
+	}
+}
+
+// takeDeps 返回从上一次调用 takeDeps 至今记录的依赖，并清空记录
+func (b *RenderContext) takeDeps() []string {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	ds := make([]string, 0, len(b.deps))
+	for k := range b.deps {
+		ds = append(ds, k)
+	}
+	sort.Strings(ds)
+	b.deps = nil
+	return ds
+}
+
+// produce 记录渲染产生的页面之外的文件（dst 中的路径）
+func (b *RenderContext) produce(files ...string) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if b.outputs == nil {
+		b.outputs = map[string]struct{}{}
+	}
+	for _, f := range files {
+		b.outputs[f] = struct{}{}
+	}
+}
+
+// takeOutputs 返回从上一次调用 takeOutputs 至今记录的文件，并清空记录
+func (b *RenderContext) takeOutputs() []string {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	files := make([]string, 0, len(b.outputs))
+	for k := range b.outputs {
+		files = append(files, k)
+	}
+	sort.Strings(files)
+	b.outputs = nil
+	return files
+}
+
+// useAssets 记录内容中引用的文件：构建时复制到 __source 下，文件内容变化时需要重新渲染页面
+func (b *RenderContext) useAssets(as Assets) {
+	if len(as) == 0 {
+		return
+	}
+	b.Save("assets", as)
+	b.dependOn(as...)
+	for _, a := range as {
+		b.produce(assetDistFile(a))
+	}
+}
+
+// fork 返回一个共享缓存的 RenderContext，用于在其他协程中渲染，渲染完成后需要使用 merge 合并数据。
+func (b *RenderContext) fork() *RenderContext {
+	return &RenderContext{
+		cache: b.cache,
+		timer: b.timer,
+		debug: b.debug,
+
+		showDrafts: b.showDrafts,
+		themeFs:    b.themeFs,
+		fileDates:  b.fileDates,
+	}
+}
+
+// loadingExt 记录执行主题模块时读取的内容的扩展名
+func (b *RenderContext) loadingExt(ext string) {
+	if !b.themeLoading {
+		return
+	}
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if b.loadingExts == nil {
+		b.loadingExts = map[string]struct{}{}
+	}
+	b.loadingExts[ext] = struct{}{}
+}
+
+// merge 合并 fork 出的 RenderContext 中保存的数据
+func (b *RenderContext) merge(o *RenderContext) {
+	o.GetDataAll(func(key, value any) bool {
+		for _, v := range value.([]interface{}) {
+			b.Save(key.(string), v)
+		}
+		return true
+	})
+}
+
 func NewRenderContext() *RenderContext {
 	c, _ := lru.New[string, interface{}](100)
 	return &RenderContext{
@@ -173,6 +415,8 @@ func NewRenderContext() *RenderContext {
 		timer: &timetrack.TimeTracker{},
 		debug: false,
 		data:  sync.Map{},
+
+		fileDates: &fileDates{},
 	}
 }
 
@@ -185,6 +429,46 @@ type Option struct {
 type StdFileSystem struct {
 }
 
+// newSourceJsx 创建执行 source 中文件的 jsx，f 为 md 与 mdx 额外开启的功能
+func newSourceJsx(stdFs fs.FS, f markdownFeatures) (*gojsx.Jsx, error) {
+	return gojsx.NewJsx(gojsx.Option{
+		Debug: false,
+		Fs:    stdFs,
+		Transformer: gojsx.NewEsBuildTransform(gojsx.EsBuildTransformOptions{
+			MarkdownOptions: markdownOptions(f),
+		}),
+	})
+}
+
+// markdownJsx 返回渲染 md 与 mdx 使用的 jsx，只有开启了 math 时才解析 $...$，否则 $ 会保持原样；
+// 只有开启了 highlight 时代码块才会保留 data-meta
+func (b *Hollow) markdownJsx(ctx *RenderContext) *gojsx.Jsx {
+	c, err := b.LoadConfig(ctx)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		log.Warnf("LoadConfig for markdown error: %v", err)
+	}
+	f := markdownFeatures{math: c.Hollow.Math.Enable, codeMeta: c.Hollow.Highlight.Enable}
+	if f == (markdownFeatures{}) {
+		return b.jsx
+	}
+
+	b.markdownJsxLock.Lock()
+	defer b.markdownJsxLock.Unlock()
+	if x, ok := b.markdownJsxs[f]; ok {
+		return x
+	}
+	x, err := newSourceJsx(b.sourceStdFs, f)
+	if err != nil {
+		log.Warnf("create jsx for markdown error: %v", err)
+		return b.jsx
+	}
+	if b.markdownJsxs == nil {
+		b.markdownJsxs = map[markdownFeatures]*gojsx.Jsx{}
+	}
+	b.markdownJsxs[f] = x
+	return x
+}
+
 func (f StdFileSystem) Open(name string) (fs.File, error) {
 	return os.Open(name)
 }
@@ -200,10 +484,7 @@ func NewHollow(o Option) (*Hollow, error) {
 	stdFs := gobilly.NewStdFs(o.SourceFs)
 
 	var err error
-	jsx, err := gojsx.NewJsx(gojsx.Option{
-		Debug: false,
-		Fs:    stdFs,
-	})
+	jsx, err := newSourceJsx(stdFs, markdownFeatures{})
 	if err != nil {
 		return nil, err
 	}
@@ -219,6 +500,9 @@ func NewHollow(o Option) (*Hollow, error) {
 		sourceStdFs:      stdFs,
 		themeLoaderCache: c,
 	}
+	b.contentLoaders = b.builtinContentLoaders()
+	b.diagramRenderers = builtinDiagramRenderers()
+	b.diagramErrors = &sync.Map{}
 
 	b.asyncTask.AddListener(func(task *asynctask.Task, event *asynctask.Event) {
 		if event.IsDone {
@@ -234,7 +518,18 @@ func NewHollow(o Option) (*Hollow, error) {
 type ExecOption struct {
 	Log *zap.SugaredLogger
 
-	IsDev bool // 开发环境每次都会读取最新的文件，而生成环境会缓存
+	IsDev       bool // 开发环境每次都会读取最新的文件，而生成环境会缓存
+	LiveReload  bool // 在 html 页面中注入自动刷新的脚本，只有同时运行了 Watch 与 DevService 时才需要开启
+	Incremental bool // 增量构建，跳过没有变化的页面，删除不再生成的页面
+	Workers     int  // 并行渲染页面的协程数，默认为 1
+
+	ContinueOnError bool   // 页面渲染失败时继续渲染其他页面，在构建结束时返回 *BuildError
+	Strict          bool   // 存在加载失败的内容（会被渲染成错误页面）时构建失败
+	ReportFile      string // 构建完成后将所有错误以 json 格式写入该文件，为空则不写入
+
+	ShowDrafts bool // 显示草稿（draft: true）、未到发布时间（publish_date）与已过期（expiry_date）的内容
+
+	RefreshTheme bool // 重新下载远程主题，默认使用 CacheFs 中已下载的主题（git 主题仍以 hollow.lock 为准）
 }
 
 // Build 生成静态源文件
@@ -243,16 +538,28 @@ func (b *Hollow) Build(ctx *RenderContext, distPath string, o ExecOption) error
 }
 
 func (b *Hollow) loadTheme(ctx *RenderContext, url string, refresh bool, enableAsync bool) (ThemeExport, fs.FS, *asynctask.Task, error) {
-	themeLoader, err := b.getThemeLoader(url)
+	themeLoader, err := b.getThemeLoader(url, b.themeSha256(ctx))
 	if err != nil {
 		return ThemeExport{}, nil, nil, err
 	}
 
 	//log.Infof("-------- loadTheme -------")
+	// 主题的加载器在主题模块执行完成后才能安装，每个 RenderContext 只使用自己的主题运行时中的加载器
+	ctx.themeLoaders = nil
+	ctx.themeLoading = true
 	themeModule, themeFs, task, err := themeLoader.Load(ctx, refresh, enableAsync, gojsx.WithNativeModule("@bysir/hollow", b.ExportFunc(ctx)))
+	ctx.themeLoading = false
 	if err != nil {
 		return ThemeExport{}, nil, nil, fmt.Errorf("load theme '%s' error: %w", url, err)
 	}
+	// 在主题顶层读取的内容没有使用主题的加载器，并且已经被缓存，直接报错而不是生成缺少内容的页面
+	for ext := range themeModule.Loaders {
+		if _, ok := ctx.loadingExts[ext]; ok {
+			return ThemeExport{}, nil, nil, fmt.Errorf("load theme '%s' error: contents are read at the top level of the theme before the loader of '%v' is installed, read them in components or getPaths instead", url, ext)
+		}
+	}
+	ctx.themeLoaders = b.themeContentLoaders(themeModule)
+	ctx.themeFs = themeFs
 
 	return themeModule, themeFs, task, nil
 }
@@ -275,16 +582,30 @@ func (b *Hollow) RenderFile(path string) (c Content, err error) {
 
 func (b *Hollow) BuildToFs(ctx *RenderContext, dst billy.Filesystem, o ExecOption) error {
 	start := time.Now()
+	ctx.showDrafts = o.ShowDrafts
 	conf, err := b.LoadConfig(ctx)
 	if err != nil {
 		return fmt.Errorf("LoadConfig error: %w", err)
 	}
-	themeUrl := b.prepareThemeUrl(conf.Hollow.Theme, b.FixedTheme)
+	themeUrl := b.prepareThemeUrl(conf.Hollow.theme(), b.FixedTheme)
+
+	themeModule, themeFs, _, err := b.loadTheme(ctx, themeUrl, o.RefreshTheme, false)
+	if err != nil {
+		return err
+	}
+	// 加载配置与主题时读取的文件会影响所有页面
+	ctx.dependOn(configFiles...)
+	globalDeps := ctx.takeDeps()
 
-	themeModule, themeFs, _, err := b.loadTheme(ctx, themeUrl, true, false)
+	themeModule.Pages, err = themeModule.ExpandPages()
 	if err != nil {
 		return err
 	}
+	// getPaths 读取的文件只决定有哪些页面与页面的参数，已经通过页面列表与 paramsHash 记录，不需要作为全局依赖
+	ctx.takeDeps()
+	// 加载主题与页面列表时产生的文件每次构建都会重新生成
+	globalOutputs := ctx.takeOutputs()
+
 	l := b.log
 	if o.Log != nil {
 		l = o.Log
@@ -292,27 +613,86 @@ func (b *Hollow) BuildToFs(ctx *RenderContext, dst billy.Filesystem, o ExecOptio
 
 	l = l.Named("[Build]\t")
 
+	var prev *buildManifest
+	if o.Incremental {
+		prev = readBuildManifest(dst)
+	}
+	next := newBuildManifest()
+	next.Drafts = o.ShowDrafts
+	next.Outputs = globalOutputs
+	hasher := newSourceHasher(b.sourceStdFs)
+
+	next.Theme, err = hashThemeFs(themeUrl, themeFs)
+	if err != nil {
+		return fmt.Errorf("hash theme error: %w", err)
+	}
+	next.Global = hasher.hashAll(globalDeps)
+	reusable := prev.reusable(next)
+
+	distFiles := make([]string, len(themeModule.Pages))
+	paramsHashes := make([]string, len(themeModule.Pages))
+	var renderIndexes []int
 	for i, p := range themeModule.Pages {
-		name := p.GetPath()
-		body, err := p.Render()
-		if err != nil {
-			return fmt.Errorf("render page '%v' error: %w", name, err)
+		distFile := p.distFile()
+		distFiles[i] = distFile
+		paramsHashes[i] = p.paramsHash()
+
+		last, hasLast := prev.page(distFile)
+		if reusable && hasLast && last.Params == paramsHashes[i] && hasher.unchanged(last.Sources) && existFile(dst, distFile) && existFiles(dst, last.Outputs) {
+			next.Pages[distFile] = last
+			l.Infof("Skip file [%03d]: %v", i, distFile)
+			continue
 		}
-		var distFile string
+		renderIndexes = append(renderIndexes, i)
+	}
 
-		// 如果有扩展名，则说明是文件
-		if filepath.Ext(name) != "" {
-			distFile = name
-		} else {
-			// 否则存入文件夹
-			distFile = filepath.Join(name, "index.html")
+	results, err := b.renderPages(ctx, themeUrl, themeModule, renderIndexes, o.Workers)
+	if err != nil {
+		return err
+	}
+
+	var report BuildReport
+	// 渲染失败的页面与其产生的文件不会被删除
+	failed := map[string]bool{}
+	for _, i := range renderIndexes {
+		r := results[i]
+		distFile := distFiles[i]
+		if r.err != nil {
+			name := themeModule.Pages[i].GetPath()
+			if !o.ContinueOnError {
+				return fmt.Errorf("render page '%v' error: %w", name, r.err)
+			}
+			// 保留上一次构建的文件，也不记录到 manifest 中，下一次构建会重新渲染
+			failed[distFile] = true
+			if last, ok := prev.page(distFile); ok {
+				for _, o := range last.Outputs {
+					failed[o] = true
+				}
+			}
+			report.Pages = append(report.Pages, newBuildReportItem(name, r.err))
+			l.Errorf("Render page [%03d] '%v' error: %v", i, name, r.err)
+			continue
+		}
+
+		hash := util.MD5(r.body)
+		next.Pages[distFile] = buildManifestPage{
+			Hash:    hash,
+			Params:  paramsHashes[i],
+			Sources: hasher.hashAll(r.deps),
+			Outputs: r.outputs,
+		}
+		last, hasLast := prev.page(distFile)
+		if hasLast && last.Hash == hash && existFile(dst, distFile) {
+			l.Infof("Unchanged file [%03d]: %v", i, distFile)
+			continue
 		}
 
 		f, err := dst.Create(distFile)
 		if err != nil {
 			return fmt.Errorf("create file '%v' error: %w", distFile, err)
 		}
-		_, err = f.Write([]byte(body))
+		_, err = f.Write([]byte(r.body))
+		f.Close()
 		if err != nil {
 			return err
 		}
@@ -320,6 +700,17 @@ func (b *Hollow) BuildToFs(ctx *RenderContext, dst billy.Filesystem, o ExecOptio
 		l.Infof("Create file [%03d]: %v", i, distFile)
 	}
 
+	for _, s := range prev.stale(next) {
+		if failed[s] {
+			continue
+		}
+		err = dst.Remove(s)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("remove file '%v' error: %w", s, err)
+		}
+		l.Infof("Remove file: %v", s)
+	}
+
 	for _, a := range themeModule.Assets {
 		err = copyDir(a, "", themeFs, dst)
 		if err != nil {
@@ -340,16 +731,197 @@ func (b *Hollow) BuildToFs(ctx *RenderContext, dst billy.Filesystem, o ExecOptio
 	a := ctx.GetData("assets")
 	for _, a := range a {
 		for _, a := range a.(Assets) {
-			if err = copyFile(a, filepath.Join("__source", a), b.sourceStdFs, dst); err != nil {
+			if err = copyFile(a, assetDistFile(a), b.sourceStdFs, dst); err != nil {
 				log.Warnf("exportFile error: %s", err)
 			}
 		}
 	}
 
+	if err = b.buildImages(ctx, conf.Hollow.Images, dst, l); err != nil {
+		return fmt.Errorf("build images error: %w", err)
+	}
+
+	feeds, err := b.buildFeeds(ctx, conf.Hollow)
+	if err != nil {
+		return fmt.Errorf("build feeds error: %w", err)
+	}
+	for _, f := range feeds {
+		if hasFile(distFiles, f.Name) {
+			l.Warnf("Skip feed %v: the theme already has a page with the same path", f.Name)
+			continue
+		}
+		if err = writeFile(dst, f.Name, f.Body); err != nil {
+			return err
+		}
+		l.Infof("Create file: %v", f.Name)
+	}
+
+	searchFiles, err := b.buildSearch(ctx, conf.Hollow.Search, newSearchPages(themeModule.Pages, distFiles, next))
+	if err != nil {
+		return fmt.Errorf("build search index error: %w", err)
+	}
+	for _, f := range searchFiles {
+		if err = writeFile(dst, f.Name, f.Body); err != nil {
+			return err
+		}
+	}
+	if len(searchFiles) != 0 {
+		l.Infof("Create search index: %v (%v shards)", searchFiles[0].Name, len(searchFiles)-1)
+	}
+
+	if conf.Hollow.Highlight.hasStylesheet() {
+		css, err := conf.Hollow.Highlight.css()
+		if err != nil {
+			return fmt.Errorf("build highlight css error: %w", err)
+		}
+		if err = writeFile(dst, conf.Hollow.Highlight.stylesheet(), css); err != nil {
+			return err
+		}
+		l.Infof("Create file: %v", conf.Hollow.Highlight.stylesheet())
+	}
+
+	if conf.Hollow.Sitemap.Enable {
+		if conf.Hollow.BaseUrl == "" {
+			return fmt.Errorf("sitemap requires 'base_url' in config")
+		}
+		// 主题或者项目中已经提供了 sitemap.xml 或 robots.txt 时不覆盖
+		if hasOwnFile("sitemap.xml", distFiles, themeModule.Assets, themeFs, conf.Hollow.Assets, b.sourceStdFs) {
+			l.Warnf("Skip sitemap.xml: the theme or project already has the file")
+		} else {
+			sitemap, err := b.buildSitemap(conf.Hollow.BaseUrl, themeModule.Pages, distFiles, next)
+			if err != nil {
+				return fmt.Errorf("build sitemap error: %w", err)
+			}
+			if err = writeFile(dst, "sitemap.xml", sitemap); err != nil {
+				return err
+			}
+			l.Infof("Create file: sitemap.xml")
+		}
+
+		if !hasOwnFile("robots.txt", distFiles, themeModule.Assets, themeFs, conf.Hollow.Assets, b.sourceStdFs) {
+			if err = writeFile(dst, "robots.txt", buildRobots(conf.Hollow.BaseUrl)); err != nil {
+				return err
+			}
+			l.Infof("Create file: robots.txt")
+		}
+	}
+
+	if o.Incremental {
+		// 复用的页面中的内容状态变化时间记录在上一次的 manifest 中
+		if t, ok := nextStatusChange(ctx); ok {
+			next.Expire = t.Unix()
+		}
+		if reusable && prev.Expire != 0 && (next.Expire == 0 || prev.Expire < next.Expire) {
+			next.Expire = prev.Expire
+		}
+		err = next.write(dst)
+		if err != nil {
+			return err
+		}
+	}
+
+	report.Contents = contentErrors(ctx)
+	for _, c := range report.Contents {
+		l.Warnf("Load content '%v' error: %v", c.Path, c.Error)
+	}
+	if o.ReportFile != "" {
+		err = report.writeFile(o.ReportFile)
+		if err != nil {
+			return err
+		}
+		l.Infof("Write report: %v", o.ReportFile)
+	}
+	if report.failed(o.Strict) {
+		return &BuildError{Report: report}
+	}
+
 	l.Infof("Done in %v", time.Now().Sub(start))
 	return nil
 }
 
+type renderResult struct {
+	body    string
+	deps    []string // 渲染时读取的 source 文件
+	outputs []string // 渲染时产生的页面之外的文件
+	err     error
+}
+
+// renderPages 使用 workers 个协程渲染 theme.Pages 中下标为 indexes 的页面，返回的结果与 theme.Pages 下标一一对应。
+// goja 运行时不是协程安全的，所以第一个 worker 直接使用已经加载好的主题，其他 worker 会重新加载一次主题，拥有独立的运行时与 RenderContext。
+func (b *Hollow) renderPages(ctx *RenderContext, themeUrl string, theme ThemeExport, indexes []int, workers int) ([]renderResult, error) {
+	results := make([]renderResult, len(theme.Pages))
+	if workers > len(indexes) {
+		workers = len(indexes)
+	}
+	if workers < 1 {
+		workers = 1
+	}
+
+	jobs := make(chan int, len(indexes))
+	for _, i := range indexes {
+		jobs <- i
+	}
+	close(jobs)
+
+	render := func(ctx *RenderContext, pages Pages) {
+		for i := range jobs {
+			body, err := pages[i].Render()
+			results[i] = renderResult{
+				body:    body,
+				deps:    ctx.takeDeps(),
+				outputs: ctx.takeOutputs(),
+				err:     err,
+			}
+		}
+	}
+
+	var wg sync.WaitGroup
+	errs := make([]error, workers)
+	forks := make([]*RenderContext, workers)
+	for w := 1; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+
+			fork := ctx.fork()
+			forks[w] = fork
+			t, _, _, err := b.loadTheme(fork, themeUrl, false, false)
+			if err != nil {
+				errs[w] = err
+				return
+			}
+			t.Pages, err = t.ExpandPages()
+			if err != nil {
+				errs[w] = err
+				return
+			}
+			if len(t.Pages) != len(theme.Pages) {
+				errs[w] = fmt.Errorf("theme exports %v pages in worker %v, but %v in main worker", len(t.Pages), w, len(theme.Pages))
+				return
+			}
+			// 丢弃加载主题时产生的依赖与文件，它们已经记录在主 worker 中
+			fork.takeDeps()
+			fork.takeOutputs()
+
+			render(fork, t.Pages)
+		}(w)
+	}
+
+	render(ctx, theme.Pages)
+	wg.Wait()
+
+	for w, fork := range forks {
+		if errs[w] != nil {
+			return nil, fmt.Errorf("load theme for worker %v error: %w", w, errs[w])
+		}
+		if fork != nil {
+			ctx.merge(fork)
+		}
+	}
+
+	return results, nil
+}
+
 func (b *Hollow) BuildAndPublish(ctx *RenderContext, dst billy.Filesystem, o ExecOption) error {
 	err := b.BuildToFs(ctx, dst, o)
 	if err != nil {
@@ -458,11 +1030,31 @@ func (d *DirFs) Open(name string) (http.File, error) {
 }
 
 type HollowConfig struct {
-	Theme  string    `json:"theme"`
-	Deploy GitRepo   `json:"deploy"`
-	Source GitRepo   `json:"source"`
-	Oss    ConfigOss `json:"oss"`
-	Assets Assets    `json:"assets"`
+	Theme       string          `json:"theme"`
+	ThemeSha256 string          `json:"theme_sha256"` // 压缩包主题（.zip / .tar.gz）的 sha256，不一致时报错
+	Themes      []string        `json:"themes"`       // 多层主题，后面的主题中的文件覆盖前面的主题，配置后忽略 Theme
+	Deploy      GitRepo         `json:"deploy"`
+	Source      GitRepo         `json:"source"`
+	Oss         ConfigOss       `json:"oss"`
+	Assets      Assets          `json:"assets"`
+	BaseUrl     string          `json:"base_url"` // 网站地址，如 https://gohollow.top
+	Sitemap     ConfigSitemap   `json:"sitemap"`
+	Feeds       []ConfigFeed    `json:"feeds"`
+	Languages   ConfigLanguages `json:"languages"`
+	Taxonomy    ConfigTaxonomy  `json:"taxonomy"`
+	GitDates    bool            `json:"git_dates"` // 使用 git 提交历史设置内容的 created 与 updated
+	Search      ConfigSearch    `json:"search"`
+	Images      ConfigImages    `json:"images"`
+	Highlight   ConfigHighlight `json:"highlight"`
+	Math        ConfigMath      `json:"math"`
+}
+
+// theme 返回主题地址，多层主题使用 themeLayerSep 连接
+func (c HollowConfig) theme() string {
+	if len(c.Themes) != 0 {
+		return strings.Join(c.Themes, themeLayerSep)
+	}
+	return c.Theme
 }
 
 type Config struct {
@@ -483,6 +1075,10 @@ type ConfigGit struct {
 	Source GitRepo `json:"source" yaml:"source"`
 }
 
+type ConfigSitemap struct {
+	Enable bool `json:"enable" yaml:"enable"` // 构建时生成 sitemap.xml 与 robots.txt，需要配置 base_url
+}
+
 type ConfigOss struct {
 	AccessKey string `yaml:"access_key"`
 	SecretKey string `yaml:"secret_key"`
@@ -502,12 +1098,24 @@ func loadYamlConfig(body string, expandEnv bool) (con Config, err error) {
 	}
 
 	type YamlConfig struct {
-		Theme       string      `yaml:"theme"`
-		Deploy      GitRepo     `yaml:"deploy"`
-		Source      GitRepo     `yaml:"source"`
-		Oss         ConfigOss   `yaml:"oss"`
-		Assets      Assets      `yaml:"assets"`
-		ThemeConfig interface{} `yaml:"theme_config"`
+		Theme       string          `yaml:"theme"`
+		ThemeSha256 string          `yaml:"theme_sha256"`
+		Themes      []string        `yaml:"themes"`
+		Deploy      GitRepo         `yaml:"deploy"`
+		Source      GitRepo         `yaml:"source"`
+		Oss         ConfigOss       `yaml:"oss"`
+		Assets      Assets          `yaml:"assets"`
+		BaseUrl     string          `yaml:"base_url"`
+		Sitemap     ConfigSitemap   `yaml:"sitemap"`
+		Feeds       []ConfigFeed    `yaml:"feeds"`
+		Languages   ConfigLanguages `yaml:"languages"`
+		Taxonomy    ConfigTaxonomy  `yaml:"taxonomy"`
+		GitDates    bool            `yaml:"git_dates"`
+		Search      ConfigSearch    `yaml:"search"`
+		Images      ConfigImages    `yaml:"images"`
+		Highlight   ConfigHighlight `yaml:"highlight"`
+		Math        ConfigMath      `yaml:"math"`
+		ThemeConfig interface{}     `yaml:"theme_config"`
 	}
 
 	var yc YamlConfig
@@ -520,11 +1128,23 @@ func loadYamlConfig(body string, expandEnv bool) (con Config, err error) {
 
 	con = Config{
 		Hollow: HollowConfig{
-			Theme:  yc.Theme,
-			Deploy: yc.Deploy,
-			Source: yc.Source,
-			Oss:    yc.Oss,
-			Assets: yc.Assets,
+			Theme:       yc.Theme,
+			ThemeSha256: yc.ThemeSha256,
+			Themes:      yc.Themes,
+			Deploy:      yc.Deploy,
+			Source:      yc.Source,
+			Oss:         yc.Oss,
+			Assets:      yc.Assets,
+			BaseUrl:     yc.BaseUrl,
+			Sitemap:     yc.Sitemap,
+			Feeds:       yc.Feeds,
+			Languages:   yc.Languages,
+			Taxonomy:    yc.Taxonomy,
+			GitDates:    yc.GitDates,
+			Search:      yc.Search,
+			Images:      yc.Images,
+			Highlight:   yc.Highlight,
+			Math:        yc.Math,
 		},
 		Theme: yc.ThemeConfig,
 	}
@@ -604,12 +1224,26 @@ func prepareThemeUrl(url string, defaultProtocol string) string {
 	}
 }
 
+// themeSha256 返回配置中的主题 sha256，使用 FixedTheme 时不校验
+func (b *Hollow) themeSha256(ctx *RenderContext) string {
+	if b.FixedTheme != "" {
+		return ""
+	}
+	conf, err := b.LoadConfig(ctx)
+	if err != nil {
+		return ""
+	}
+	return conf.Hollow.ThemeSha256
+}
+
 // getThemeLoader 返回主题加载器，支持以下协议的地址。
+// https:// : .zip / .tar.gz 压缩包，sha256 不为空时校验压缩包
 // https:// : git
 // file:// : relative or absolute path, e.g. file://usr/bysir/xx , file://./bysir/xx
 // source:// : relative path of source fs
-func (b *Hollow) getThemeLoader(url string) (ThemeLoader, error) {
-	v, ok := b.themeLoaderCache.Get(url)
+func (b *Hollow) getThemeLoader(url string, sha256 string) (ThemeLoader, error) {
+	cacheKey := url + "@" + sha256
+	v, ok := b.themeLoaderCache.Get(cacheKey)
 	if ok {
 		return v, nil
 	}
@@ -617,9 +1251,31 @@ func (b *Hollow) getThemeLoader(url string) (ThemeLoader, error) {
 	log.Infof("new ThemeLoader %v", url)
 
 	var tl ThemeLoader
-	switch {
-	case strings.HasPrefix(url, "http://"), strings.HasPrefix(url, "https://"):
-		tl = NewGitThemeLoader(b.asyncTask, url, b.CacheFs)
+	switch layers := splitThemeLayers(url); {
+	case len(layers) > 1:
+		// theme_sha256 与 hollow.lock 只能对应一个主题，所以只能有一层远程主题
+		var remotes []string
+		for _, l := range layers {
+			if strings.HasPrefix(l, "http://") || strings.HasPrefix(l, "https://") {
+				remotes = append(remotes, l)
+			}
+		}
+		if len(remotes) > 1 {
+			return nil, fmt.Errorf("only one remote theme (git or archive) can be used in themes, got: %v", strings.Join(remotes, ", "))
+		}
+		fls := make([]themeFsLoader, len(layers))
+		for i, l := range layers {
+			sub, err := b.getThemeLoader(l, sha256)
+			if err != nil {
+				return nil, err
+			}
+			fl, ok := sub.(themeFsLoader)
+			if !ok {
+				return nil, fmt.Errorf("theme '%v' can not be used as a layer", l)
+			}
+			fls[i] = fl
+		}
+		tl = NewLayerThemeLoader(fls)
 	case strings.HasPrefix(url, "file://"):
 		pa := strings.TrimPrefix(url, "file://")
 		if strings.HasPrefix(pa, ".") {
@@ -636,10 +1292,14 @@ func (b *Hollow) getThemeLoader(url string) (ThemeLoader, error) {
 			return nil, err
 		}
 		tl = NewFsThemeLoader(gobilly.NewStdFs(subFs))
+	case isArchiveUrl(url):
+		tl = NewArchiveThemeLoader(b.asyncTask, url, sha256, b.CacheFs)
+	case strings.HasPrefix(url, "http://"), strings.HasPrefix(url, "https://"):
+		tl = NewGitThemeLoader(b.asyncTask, url, b.CacheFs, b.SourceFs)
 	default:
 		return nil, fmt.Errorf("unsupported protocol, url: %v", url)
 	}
-	b.themeLoaderCache.Add(url, tl)
+	b.themeLoaderCache.Add(cacheKey, tl)
 	return tl, nil
 }
 
@@ -675,29 +1335,40 @@ window.onload = function(){ window.RenderError({msg: %s})}
 	writer.WriteHeader(400)
 }
 
+// prepareThemeUrl 为主题地址添加默认协议，fixedTheme 不为空时会代替项目中的所有主题
 func (b *Hollow) prepareThemeUrl(projectTheme string, fixedTheme string) string {
-	themeUrl := prepareThemeUrl(projectTheme, "source://")
 	if fixedTheme != "" {
-		themeUrl = prepareThemeUrl(fixedTheme, "file://")
+		return prepareThemeUrl(fixedTheme, "file://")
 	}
-	return themeUrl
+	layers := splitThemeLayers(projectTheme)
+	if len(layers) == 0 {
+		return prepareThemeUrl("", "source://")
+	}
+	for i, l := range layers {
+		layers[i] = prepareThemeUrl(l, "source://")
+	}
+	return strings.Join(layers, themeLayerSep)
 }
 
-func (b *Hollow) ServiceHandle(o ExecOption) func(writer http.ResponseWriter, request *http.Request) {
-	var assetsHandler http.Handler
-	var themeModule ThemeExport
+// serveState 是开发服务中一次请求所需的主题与配置，dev 环境每次请求都会重新准备，不同请求之间不共享
+type serveState struct {
+	assetsHandler http.Handler
+	themeModule   ThemeExport
+	themeFs       fs.FS
+	projectConf   Config
+}
 
-	prepare := func(ctx *RenderContext, opt *PrepareOpt) (asyncTaskKey string, err error) {
+func (b *Hollow) ServiceHandle(o ExecOption) func(writer http.ResponseWriter, request *http.Request) {
+	prepare := func(ctx *RenderContext, opt *PrepareOpt) (state *serveState, asyncTaskKey string, err error) {
 		end := ctx.timerStart("config")
 
 		var projectConf Config
-
 		projectConf, err = b.LoadConfig(ctx)
 		if err != nil {
 			if errors.Is(err, os.ErrNotExist) {
 
 			} else {
-				return "", err
+				return nil, "", err
 			}
 		}
 		end()
@@ -707,24 +1378,23 @@ func (b *Hollow) ServiceHandle(o ExecOption) func(writer http.ResponseWriter, re
 			refresh = true
 		}
 
-		themeUrl := b.prepareThemeUrl(projectConf.Hollow.Theme, b.FixedTheme)
-		var themeFs fs.FS
+		themeUrl := b.prepareThemeUrl(projectConf.Hollow.theme(), b.FixedTheme)
 		var task *asynctask.Task
 		end = ctx.timerStart("theme")
-		themeModule, themeFs, task, err = b.loadTheme(ctx, themeUrl, refresh, true)
+		themeModule, themeFs, task, err := b.loadTheme(ctx, themeUrl, refresh, true)
 		if err != nil {
-			return "", err
+			return nil, "", err
 		}
 		end()
 		if task != nil {
-			return task.Key, nil
+			return nil, task.Key, nil
 		}
 
 		var dirs MuitDir
 		for _, dir := range themeModule.Assets {
 			sub, err := fs.Sub(themeFs, dir)
 			if err != nil {
-				return "", fmt.Errorf("sub fs '%v' error: %w", dir, err)
+				return nil, "", fmt.Errorf("sub fs '%v' error: %w", dir, err)
 			}
 
 			dirs = append(dirs, &DirFs{
@@ -735,7 +1405,7 @@ func (b *Hollow) ServiceHandle(o ExecOption) func(writer http.ResponseWriter, re
 		for _, dir := range projectConf.Hollow.Assets {
 			sub, err := fs.Sub(gobilly.NewStdFs(b.SourceFs), dir)
 			if err != nil {
-				return "", fmt.Errorf("sub fs '%v' error: %w", dir, err)
+				return nil, "", fmt.Errorf("sub fs '%v' error: %w", dir, err)
 			}
 			dirs = append(dirs, &DirFs{
 				fs: http.FS(sub),
@@ -748,14 +1418,20 @@ func (b *Hollow) ServiceHandle(o ExecOption) func(writer http.ResponseWriter, re
 			fs:          http.FS(gobilly.NewStdFs(b.SourceFs)),
 		})
 
-		assetsHandler = http_file_server.FileServer(dirs)
-		return "", nil
+		return &serveState{
+			assetsHandler: http_file_server.FileServer(dirs),
+			themeModule:   themeModule,
+			themeFs:       themeFs,
+			projectConf:   projectConf,
+		}, "", nil
 	}
 
 	// 不是 dev 环境只会加载一次主题，而不是每次刷新页面都加载
+	var staticState *serveState
 	if !o.IsDev {
 		ctx := NewRenderContext()
-		task, err := prepare(ctx, nil)
+		ctx.showDrafts = o.ShowDrafts
+		state, task, err := prepare(ctx, nil)
 		if err != nil {
 			return func(writer http.ResponseWriter, request *http.Request) {
 				handleError(err, writer, request)
@@ -767,18 +1443,23 @@ func (b *Hollow) ServiceHandle(o ExecOption) func(writer http.ResponseWriter, re
 				handleAsyncTask(task, "", writer, request)
 			}
 		}
+		staticState = state
 	}
 
 	return func(writer http.ResponseWriter, request *http.Request) {
 		reqPath := strings.Trim(request.URL.Path, "/")
 
 		ctx := NewRenderContext()
+		ctx.showDrafts = o.ShowDrafts
 
+		state := staticState
 		if o.IsDev {
 			opt := PrepareOpt{
 				NoCache: request.Header.Get("Cache-Control") == "no-cache",
 			}
-			task, err := prepare(ctx, &opt)
+			var task string
+			var err error
+			state, task, err = prepare(ctx, &opt)
 			if err != nil {
 				handleError(err, writer, request)
 				return
@@ -788,19 +1469,73 @@ func (b *Hollow) ServiceHandle(o ExecOption) func(writer http.ResponseWriter, re
 				return
 			}
 		}
-		for _, p := range themeModule.Pages {
-			if reqPath == p.GetPath() {
-				body, err := p.Render()
-				if err != nil {
-					handleError(err, writer, request)
-					return
-				}
-				writer.WriteHeader(200)
-				writer.Write([]byte(body))
+		themeModule, themeFs, projectConf := state.themeModule, state.themeFs, state.projectConf
+		if p, ok, err := themeModule.MatchPage(ctx, reqPath); err != nil {
+			handleError(err, writer, request)
+			return
+		} else if ok {
+			body, err := p.Render()
+			if err != nil {
+				handleError(err, writer, request)
 				return
 			}
+			if o.LiveReload && isHtmlPath(reqPath) {
+				body = injectLiveReload(body)
+			}
+			writer.WriteHeader(200)
+			writer.Write([]byte(body))
+			return
+		}
+		if f, ok, err := b.matchFeed(ctx, projectConf.Hollow, reqPath); err != nil {
+			handleError(err, writer, request)
+			return
+		} else if ok {
+			writer.Header().Set("Content-Type", feedContentTypes[path.Base(f.Name)])
+			writer.WriteHeader(200)
+			writer.Write(f.Body)
+			return
 		}
-		assetsHandler.ServeHTTP(writer, request)
+		if v, ok := parseImageVariant(reqPath); ok {
+			// 地址中的 hash 与源文件不一致时（源文件已经修改，或者地址是伪造的）不生成图片
+			ctx.themeFs = themeFs
+			info, err := b.imageInfo(ctx, v.Source)
+			if err != nil && !errors.Is(err, fs.ErrNotExist) {
+				handleError(err, writer, request)
+				return
+			}
+			if err != nil || info.Hash != v.Hash {
+				http.NotFound(writer, request)
+				return
+			}
+			bs, err := b.imageVariantBytes(themeFs, v, projectConf.Hollow.Images.quality())
+			if err != nil {
+				handleError(err, writer, request)
+				return
+			}
+			writer.Header().Set("Content-Type", imageMimeTypes[v.Format])
+			writer.WriteHeader(200)
+			writer.Write(bs)
+			return
+		}
+		if css, ok, err := matchHighlightCss(projectConf.Hollow.Highlight, reqPath); err != nil {
+			handleError(err, writer, request)
+			return
+		} else if ok {
+			writer.Header().Set("Content-Type", "text/css; charset=utf-8")
+			writer.WriteHeader(200)
+			writer.Write(css)
+			return
+		}
+		if f, ok, err := b.matchSearch(ctx, projectConf.Hollow.Search, themeModule, reqPath); err != nil {
+			handleError(err, writer, request)
+			return
+		} else if ok {
+			writer.Header().Set("Content-Type", "application/json; charset=utf-8")
+			writer.WriteHeader(200)
+			writer.Write(f.Body)
+			return
+		}
+		state.assetsHandler.ServeHTTP(writer, request)
 	}
 }
 
@@ -931,7 +1666,7 @@ func (b *Hollow) Service(ctx context.Context, o ExecOption, addr string) error {
 			c.Error(err)
 			return
 		}
-		b.wsHub.Add(key, conn)
+		b.serveWs(key, conn)
 	})
 
 	sub, _ := fs.Sub(hollowdev.Dist, "dist")
@@ -963,13 +1698,21 @@ func (m MuitDir) Open(name string) (http.File, error) {
 }
 
 type Content struct {
-	Name       string                         `json:"name"`
-	GetContent func(opt GetContentOpt) string `json:"getContent"`
-	Meta       map[string]interface{}         `json:"meta"`
-	Ext        string                         `json:"ext"`
-	Content    string                         `json:"content"`
-	IsDir      bool                           `json:"is_dir"`
-	Toc        []*TocItem                     `json:"toc"`
+	Name         string                         `json:"name"`
+	File         string                         `json:"file"` // 源文件路径，如 contents/hello.md
+	GetContent   func(opt GetContentOpt) string `json:"getContent"`
+	Meta         map[string]interface{}         `json:"meta"`
+	Ext          string                         `json:"ext"`
+	Content      string                         `json:"content"`
+	IsDir        bool                           `json:"is_dir"`
+	Toc          []*TocItem                     `json:"toc"`
+	Lang         string                         `json:"lang"`         // 内容的语言，没有配置 languages 时为空
+	Translations []ContentTranslation           `json:"translations"` // 其他语言版本
+	Created      string                         `json:"created"`      // 第一次提交的时间，需要开启 git_dates
+	Updated      string                         `json:"updated"`      // 最后一次提交的时间，需要开启 git_dates
+	WordCount    int                            `json:"word_count"`   // 字数，中日韩文字按字计算，其他语言按单词计算
+	ReadingTime  int                            `json:"reading_time"` // 预计阅读时间，单位分钟
+	Excerpt      string                         `json:"excerpt"`      // 纯文本摘要，<!--more--> 之前的内容或者正文的前 200 个字
 
 	Assets Assets `json:"-"` // 文章中使用到的图片路径，base on content，需要复制到 statics
 }
@@ -981,8 +1724,18 @@ func (c Content) MarshalJSON() ([]byte, error) {
 		"toc":     c.Toc,
 		"ext":     c.Ext,
 		"name":    c.Name,
+		"file":    c.File,
 		"is_dir":  c.IsDir,
 		"content": c.Content,
+		"lang":    c.Lang,
+		"created": c.Created,
+		"updated": c.Updated,
+		"excerpt": c.Excerpt,
+
+		"word_count":   c.WordCount,
+		"reading_time": c.ReadingTime,
+
+		"translations": c.Translations,
 	})
 }
 
@@ -1038,9 +1791,10 @@ func (cs ContentTrees) Flat(includeDir bool) ContentTrees {
 type getBlogOption struct {
 	Sort   func(a, b interface{}) bool `json:"sort"`
 	Tree   bool                        `json:"tree"` // 传递 tree = true 则返回树结构
-	Size   int                         `json:"size"`
-	Page   int                         `json:"page"`
+	Size   int                         `json:"size"` // 每页数量，为 0 则不分页
+	Page   int                         `json:"page"` // 页码，从 1 开始
 	Filter func(a interface{}) bool    `json:"filter"`
+	Lang   string                      `json:"lang"` // 只返回该语言的内容，为空则返回所有语言
 }
 
 func (g getBlogOption) cacheKey() string {
@@ -1048,23 +1802,24 @@ func (g getBlogOption) cacheKey() string {
 }
 
 type BlogList struct {
-	Total int           `json:"total"`
+	Total int           `json:"total"` // 分页之前的总数
 	List  []ContentTree `json:"list"`
 }
 
+// getContentLoader 返回扩展名对应的内容加载器，主题中导出的加载器优先
 func (b *Hollow) getContentLoader(ctx *RenderContext, ext string) (l ContentLoader, ok bool) {
-	c, err := b.LoadConfig(ctx)
-	if err != nil {
-		// log.Warnf("LoadConfig for getContentLoader error: %v", err)
+	if l, ok := ctx.themeLoaders[ext]; ok {
+		return l, true
 	}
-	switch ext {
-	case ".md", ".mdx":
-		return NewMDLoader(c.Hollow.Assets, b.jsx, map[string]map[string]interface{}{
-			// 在 mdx 中，也可以使用 hollow
-			"@bysir/hollow": b.ExportFunc(ctx),
-		}), true
+	ctx.loadingExt(ext)
+
+	b.contentLoaderLock.RLock()
+	f, ok := b.contentLoaders[ext]
+	b.contentLoaderLock.RUnlock()
+	if !ok {
+		return nil, false
 	}
-	return nil, false
+	return f(ctx), true
 }
 
 func MapDir(fsys fs.FS, root string, fn func(path string, d fs.DirEntry) (ContentTree, bool, error)) (ContentTrees, error) {
@@ -1122,33 +1877,30 @@ func (b *Hollow) tryReadMeta(file string) map[string]interface{} {
 		return nil
 	}
 
-	body = trapBOM(body)
-
-	if bytes.HasPrefix(body, []byte("---\n")) {
-		bbs := bytes.SplitN(body, []byte("---"), 3)
-		if len(bbs) > 2 {
-			metaByte := bbs[1]
-			var meta = map[string]interface{}{}
-			err = yaml.Unmarshal(metaByte, &meta)
-			if err != nil {
-				return nil
-			}
-
-			return meta
-		}
+	meta, _, err := splitFrontMatter(body)
+	if err != nil {
+		return nil
 	}
-
-	return nil
+	return meta
 }
 
 func (b *Hollow) ExportFunc(ctx *RenderContext) map[string]interface{} {
 	return map[string]interface{}{
-		"getContents":      b.getContents(ctx),
-		"builtinAssert":    b.builtinAssert(ctx),
-		"getConfig":        b.getConfig(ctx),
-		"getContentDetail": b.getContentDetail(ctx),
-		"md":               b.md(ctx),
-		"mdx":              b.mdx(ctx),
+		"getContents":        b.getContents(ctx),
+		"builtinAssert":      b.builtinAssert(ctx),
+		"getConfig":          b.getConfig(ctx),
+		"getData":            b.getData(ctx),
+		"image":              b.image(ctx),
+		"getSearchIndexUrl":  b.getSearchIndexUrl(ctx),
+		"getHighlightCssUrl": b.getHighlightCssUrl(ctx),
+		"getContentDetail":   b.getContentDetail(ctx),
+		"getLanguages":       b.getLanguages(ctx),
+		"t":                  b.t(ctx),
+		"getTaxonomy":        b.getTaxonomy(ctx),
+		"getTerms":           b.getTerms(ctx),
+		"paginate":           b.paginate(ctx),
+		"md":                 b.md(ctx),
+		"mdx":                b.mdx(ctx),
 	}
 }
 
@@ -1161,6 +1913,13 @@ func (b *Hollow) getContents(ctx *RenderContext) func(dir string, opt getBlogOpt
 		var blogs ContentTrees
 		//var total int
 		cacheKey := fmt.Sprintf("getContents:%v%v", dir, opt)
+		ctx.dependOn(dir)
+
+		conf, err := b.LoadConfig(ctx)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			log.Warnf("LoadConfig for getContents error: %v", err)
+		}
+		languages := conf.Hollow.Languages
 
 		x, ok := ctx.cache.Get(cacheKey)
 		if ok {
@@ -1184,19 +1943,25 @@ func (b *Hollow) getContents(ctx *RenderContext) func(dir string, opt getBlogOpt
 					if !ok {
 						return ContentTree{}, true, nil
 					}
+					if lang, _ := languages.parseLang(path); opt.Lang != "" && lang != opt.Lang {
+						return ContentTree{}, true, nil
+					}
 
+					ctx.dependOn(path)
 					blog, err := loader.Load(path, false)
 
 					if err != nil {
 						err = fmt.Errorf("load blog '%v' error: %w", path, err)
 						log.Warnf("%v", err)
-						blog = b.newErrorContent(path, err)
+						blog = b.newErrorContent(ctx, path, err)
 					}
-
-					if len(blog.Assets) > 0 {
-						//log.Warnf("assets: %v", blog.Assets)
-						ctx.Save("assets", blog.Assets)
+					if !b.contentVisible(ctx, blog.Meta) {
+						return ContentTree{}, true, nil
 					}
+					blog = b.withLang(ctx, languages, path, blog)
+					blog = b.withDates(ctx, conf.Hollow, path, blog)
+
+					ctx.useAssets(blog.Assets)
 
 					contents.Store(path, blog)
 
@@ -1210,9 +1975,13 @@ func (b *Hollow) getContents(ctx *RenderContext) func(dir string, opt getBlogOpt
 
 			ts, err := MapDir(gobilly.NewStdFs(b.SourceFs), dir, func(path string, d fs.DirEntry) (ContentTree, bool, error) {
 				if d.IsDir() {
+					if opt.Lang != "" && languages.has(d.Name()) && d.Name() != opt.Lang {
+						return ContentTree{}, false, nil
+					}
 					// read dir meta
 					var mate = map[string]interface{}{}
 					metaFileName := filepath.Join(path, "meta.yaml")
+					ctx.dependOn(metaFileName)
 					bs, err := fs.ReadFile(gobilly.NewStdFs(b.SourceFs), metaFileName)
 					if err != nil {
 						if !errors.Is(err, fs.ErrNotExist) {
@@ -1225,13 +1994,7 @@ func (b *Hollow) getContents(ctx *RenderContext) func(dir string, opt getBlogOpt
 							return ContentTree{}, false, fmt.Errorf("unmarshal meta file error: %w", err)
 						}
 					}
-					// 格式化为 Mon Jan 02 2006 15:04:05 GMT-0700 (MST) 格式
-					for k, v := range mate {
-						switch t := v.(type) {
-						case time.Time:
-							mate[k] = t.Format("Mon Jan 02 2006 15:04:05 GMT-0700 (MST)")
-						}
-					}
+					formatMetaDates(mate)
 					return ContentTree{Content: Content{
 						Name: d.Name(),
 						GetContent: func(opt GetContentOpt) string {
@@ -1273,18 +2036,99 @@ func (b *Hollow) getContents(ctx *RenderContext) func(dir string, opt getBlogOpt
 			blogs.Sort(opt.Sort)
 		}
 
+		total := len(blogs)
+		if opt.Size > 0 {
+			blogs = pageSlice(blogs, opt.Page, opt.Size)
+		}
+
 		return BlogList{
-			Total: 0,
+			Total: total,
 			List:  blogs,
 		}
 	}
 }
 
+// pageSlice 返回第 page 页（从 1 开始）的数据，page 小于 1 时返回第一页
+func pageSlice[T any](list []T, page int, size int) []T {
+	if page < 1 {
+		page = 1
+	}
+	start := (page - 1) * size
+	if start >= len(list) {
+		return []T{}
+	}
+	end := start + size
+	if end > len(list) {
+		end = len(list)
+	}
+	return list[start:end]
+}
+
+type paginateOption struct {
+	Size int    `json:"size"`
+	Path string `json:"path"` // 第一页的路径，之后的页面为 {path}/page/{n}，如 posts, posts/page/2
+}
+
+type Pagination struct {
+	Page       int           `json:"page"` // 从 1 开始
+	TotalPages int           `json:"total_pages"`
+	Total      int           `json:"total"`
+	List       []interface{} `json:"list"`
+	Path       string        `json:"path"`
+	Prev       string        `json:"prev"` // 上一页的路径，没有则为空
+	Next       string        `json:"next"` // 下一页的路径，没有则为空
+}
+
+func paginationPath(base string, page int) string {
+	base = strings.Trim(base, "/")
+	if page <= 1 {
+		return base
+	}
+	return path.Join(base, "page", strconv.Itoa(page))
+}
+
+// paginate 将列表分为多页，用于生成分页列表页面，如：
+// paginate(getContents('contents').list, {size: 10, path: 'posts'}).map(p => ({path: p.path, component: () => <List {...p}/>}))
+func (b *Hollow) paginate(ctx *RenderContext) func(list []interface{}, opt paginateOption) []Pagination {
+	return func(list []interface{}, opt paginateOption) []Pagination {
+		if opt.Size <= 0 {
+			opt.Size = 10
+		}
+		totalPages := (len(list) + opt.Size - 1) / opt.Size
+		if totalPages == 0 {
+			// 没有数据时也返回一页，用于渲染空列表
+			totalPages = 1
+		}
+
+		ps := make([]Pagination, totalPages)
+		for i := range ps {
+			page := i + 1
+			p := Pagination{
+				Page:       page,
+				TotalPages: totalPages,
+				Total:      len(list),
+				List:       pageSlice(list, page, opt.Size),
+				Path:       paginationPath(opt.Path, page),
+			}
+			if page > 1 {
+				p.Prev = paginationPath(opt.Path, page-1)
+			}
+			if page < totalPages {
+				p.Next = paginationPath(opt.Path, page+1)
+			}
+			ps[i] = p
+		}
+		return ps
+	}
+}
+
 func (b *Hollow) newHtmlErrorMsg(err error) string {
 	return fmt.Sprintf("<pre><code>%v</code></pre>", html.EscapeString(err.Error()))
 }
 
-func (b *Hollow) newErrorContent(file string, err error) Content {
+// newErrorContent 返回显示错误信息的内容，并记录到 ctx 中用于生成构建报告
+func (b *Hollow) newErrorContent(ctx *RenderContext, file string, err error) Content {
+	ctx.Save("contentErrors", newBuildReportItem(file, err))
 	errHtml := b.newHtmlErrorMsg(err)
 	meta := b.tryReadMeta(file)
 	for k, v := range meta {
@@ -1297,6 +2141,7 @@ func (b *Hollow) newErrorContent(file string, err error) Content {
 
 	return Content{
 		Name: "Load error: " + file,
+		File: file,
 		GetContent: func(opt GetContentOpt) string {
 			return errHtml
 		},
@@ -1306,24 +2151,43 @@ func (b *Hollow) newErrorContent(file string, err error) Content {
 	}
 }
 
+type getContentDetailOption struct {
+	Lang string `json:"lang"` // 返回该语言的版本，不存在时返回 path 对应的内容
+}
+
 // getContentDetail 返回一个内容
-func (b *Hollow) getContentDetail(ctx *RenderContext) func(path string) Content {
-	return func(path string) Content {
+func (b *Hollow) getContentDetail(ctx *RenderContext) func(path string, opt getContentDetailOption) Content {
+	return func(path string, opt getContentDetailOption) Content {
+		c, err := b.LoadConfig(ctx)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			log.Warnf("LoadConfig for getContentDetail error: %v", err)
+		}
+		languages := c.Hollow.Languages
+		if opt.Lang != "" && len(languages.List) != 0 {
+			_, key := languages.parseLang(path)
+			if p, ok := b.translationIndex(ctx, languages, path)[key][opt.Lang]; ok && b.translationVisible(ctx, p) {
+				path = p
+			}
+		}
+
+		ctx.dependOn(path)
 		ext := filepath.Ext(path)
 		loader, ok := b.getContentLoader(ctx, ext)
 		if !ok {
 			err := fmt.Errorf("unsupported load '%v' file", ext)
 			log.Warnf("%v", err)
-			return b.newErrorContent(path, err)
+			return b.newErrorContent(ctx, path, err)
 		}
 		blog, err := loader.Load(path, true)
 		if err != nil {
 			err = fmt.Errorf("load file '%v' error: %w", path, err)
 			log.Warnf("%v", err)
-			return b.newErrorContent(path, err)
+			return b.newErrorContent(ctx, path, err)
 		}
 
-		return blog
+		ctx.useAssets(blog.Assets)
+		blog = b.withDates(ctx, c.Hollow, path, blog)
+		return b.withLang(ctx, languages, path, blog)
 	}
 }
 
@@ -1341,6 +2205,7 @@ func (b *Hollow) getConfig(ctx *RenderContext) func() interface{} {
 // builtinAssert 返回 js/css 文件编译之后内容
 func (b *Hollow) builtinAssert(ctx *RenderContext) func(file string) interface{} {
 	return func(file string) interface{} {
+		ctx.dependOn(file)
 		fi, err := easyfs.GetFile(b.sourceStdFs, file)
 		if err != nil {
 			return fmt.Sprintf("console.error('%v')", err)
@@ -1361,11 +2226,14 @@ type MdOptions struct {
 
 func (b *Hollow) md(ctx *RenderContext) func(str string, options MdOptions) string {
 	return func(str string, options MdOptions) string {
-		ex, err := b.jsx.ExecCode([]byte(str), gojsx.WithFileName("root.md"), gojsx.WithAutoExecJsx(nil))
+		ex, err := b.markdownJsx(ctx).ExecCode([]byte(str), gojsx.WithFileName("root.md"), gojsx.WithAutoExecJsx(nil))
 		if err != nil {
 			return err.Error()
 		}
 		v := ex.Default.(gojsx.VDom)
+		b.diagrams().rewrite(v)
+		b.highlighter(ctx).rewrite(v)
+		b.mathRenderer(ctx).rewrite(v)
 		s := v.Render()
 		// 支持处理只有一个 p 的情况，无法处理 <p> 1 </p> <h1> h1 </h1> <p> 2 </p>
 		if options.Unwrap && strings.Count(s, "<p>") == 1 {
@@ -1378,11 +2246,14 @@ func (b *Hollow) md(ctx *RenderContext) func(str string, options MdOptions) stri
 
 func (b *Hollow) mdx(ctx *RenderContext) func(str string, options MdOptions) string {
 	return func(str string, options MdOptions) string {
-		ex, err := b.jsx.ExecCode([]byte(str), gojsx.WithFileName("root.mdx"), gojsx.WithAutoExecJsx(nil))
+		ex, err := b.markdownJsx(ctx).ExecCode([]byte(str), gojsx.WithFileName("root.mdx"), gojsx.WithAutoExecJsx(nil))
 		if err != nil {
 			return err.Error()
 		}
 		v := ex.Default.(gojsx.VDom)
+		b.diagrams().rewrite(v)
+		b.highlighter(ctx).rewrite(v)
+		b.mathRenderer(ctx).rewrite(v)
 		s := v.Render()
 		if options.Unwrap && strings.Count(s, "<p>") == 1 {
 			s = strings.TrimPrefix(s, "<p>")
@@ -1394,8 +2265,33 @@ func (b *Hollow) mdx(ctx *RenderContext) func(str string, options MdOptions) str
 }
 
 type LookupConfig struct {
-	ThemePath  string // 主题绝对路径
-	SourcePath string // 源文件绝对路径
+	ThemePath  string   // 主题绝对路径，多层主题时为第一个本地主题
+	ThemePaths []string // 所有本地主题的绝对路径
+	SourcePath string   // 源文件绝对路径
+}
+
+// UpdateTheme 将 git 主题更新到地址中分支或者标签的最新提交，并更新 hollow.lock
+func (b *Hollow) UpdateTheme(ctx *RenderContext) (ThemeLock, error) {
+	conf, err := b.LoadConfig(ctx)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return ThemeLock{}, err
+	}
+
+	themeUrl := b.prepareThemeUrl(conf.Hollow.theme(), b.FixedTheme)
+	var gs []*GitThemeLoader
+	for _, l := range splitThemeLayers(themeUrl) {
+		tl, err := b.getThemeLoader(l, b.themeSha256(ctx))
+		if err != nil {
+			return ThemeLock{}, err
+		}
+		if g, ok := tl.(*GitThemeLoader); ok {
+			gs = append(gs, g)
+		}
+	}
+	if len(gs) != 1 {
+		return ThemeLock{}, fmt.Errorf("only one git theme can be locked, theme: %v", themeUrl)
+	}
+	return gs[0].Update(b.log)
 }
 
 // LookupConfig 返回配置信息
@@ -1410,34 +2306,41 @@ func (b *Hollow) LookupConfig(ctx *RenderContext) (LookupConfig, error) {
 		}
 	}
 
-	themeUrl := b.prepareThemeUrl(conf.Hollow.Theme, b.FixedTheme)
-
-	if strings.HasPrefix(themeUrl, "file://") {
-		themeUrl = strings.TrimPrefix(themeUrl, "file://")
-		if strings.HasPrefix(themeUrl, ".") {
-			// 相对路径
+	var themePaths []string
+	for _, themeUrl := range splitThemeLayers(b.prepareThemeUrl(conf.Hollow.theme(), b.FixedTheme)) {
+		if strings.HasPrefix(themeUrl, "file://") {
+			themeUrl = strings.TrimPrefix(themeUrl, "file://")
+			if strings.HasPrefix(themeUrl, ".") {
+				// 相对路径
+			} else {
+				// 绝对路径
+				themeUrl = "/" + themeUrl
+			}
+		} else if strings.HasPrefix(themeUrl, "source://") {
+			themeUrl = path.Join(sourcePath, strings.TrimPrefix(themeUrl, "source://"))
 		} else {
-			// 绝对路径
-			themeUrl = "/" + themeUrl
+			continue
 		}
-	} else if strings.HasPrefix(themeUrl, "source://") {
-		themeUrl = path.Join(sourcePath, strings.TrimPrefix(themeUrl, "source://"))
-	} else {
-		themeUrl = ""
-	}
-	switch {
-	case strings.HasPrefix(themeUrl, "file://"):
-		themeUrl = strings.TrimPrefix(themeUrl, "file://")
+		themePaths = append(themePaths, themeUrl)
 	}
 
-	return LookupConfig{
-		ThemePath:  themeUrl,
+	c := LookupConfig{
+		ThemePaths: themePaths,
 		SourcePath: sourcePath,
-	}, nil
+	}
+	if len(themePaths) != 0 {
+		c.ThemePath = themePaths[0]
+	}
+	return c, nil
 }
 
 type Assets []string
 
+// assetDistFile 返回内容中引用的文件复制到 dst 中的路径
+func assetDistFile(a string) string {
+	return path.Join("__source", cleanSourcePath(a))
+}
+
 func exportGojaValueToString(i interface{}) string {
 	switch t := i.(type) {
 	case goja.Value:
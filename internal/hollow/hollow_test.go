@@ -2,7 +2,11 @@ package hollow
 
 import (
 	"fmt"
+	"github.com/go-git/go-billy/v5"
+	"github.com/go-git/go-billy/v5/memfs"
 	"github.com/go-git/go-billy/v5/osfs"
+	"github.com/go-git/go-billy/v5/util"
+	"github.com/stretchr/testify/assert"
 	"github.com/zbysir/hollow/internal/pkg/db"
 	"github.com/zbysir/hollow/internal/pkg/gobilly"
 	"sync"
@@ -113,3 +117,98 @@ func TestBuildFromFs(t *testing.T) {
 		return
 	}
 }
+
+func TestParallelBuild(t *testing.T) {
+	source := memfs.New()
+	writeTestFile(t, source, "config.yml", "theme: theme\n")
+	for i := 0; i < 20; i++ {
+		writeTestFile(t, source, fmt.Sprintf("contents/%02d.md", i), fmt.Sprintf("# %v", i))
+	}
+	writeTestFile(t, source, "theme/index.jsx", `
+import {getContents, getContentDetail} from "@bysir/hollow"
+
+const Post = (props) => {
+  const c = getContentDetail(props.path)
+  return <div dangerouslySetInnerHTML={{__html: c.content}}></div>
+}
+
+const list = getContents("contents").list
+
+export default {
+  pages: list.map(i => ({path: i.name, component: () => <Post path={"contents/" + i.name + ".md"}/>})),
+  assets: [],
+}
+`)
+
+	build := func(workers int) billy.Filesystem {
+		b, err := NewHollow(Option{SourceFs: source})
+		if err != nil {
+			t.Fatal(err)
+		}
+		dst := memfs.New()
+		err = b.BuildToFs(NewRenderContext(), dst, ExecOption{Workers: workers})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return dst
+	}
+
+	serial := build(1)
+	parallel := build(4)
+	for i := 0; i < 20; i++ {
+		name := fmt.Sprintf("%02d/index.html", i)
+		s, err := util.ReadFile(serial, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p, err := util.ReadFile(parallel, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, string(s), string(p))
+		assert.Contains(t, string(p), fmt.Sprintf(">%v</h1>", i))
+	}
+}
+
+func TestGetContentsPagination(t *testing.T) {
+	b, err := NewHollow(Option{
+		SourceFs: osfs.New("./testdata"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	contents := b.getContents(NewRenderContext())
+	all := contents("a", getBlogOption{})
+	assert.Equal(t, 2, all.Total)
+	assert.Len(t, all.List, 2)
+
+	p2 := contents("a", getBlogOption{Size: 1, Page: 2})
+	assert.Equal(t, 2, p2.Total)
+	if assert.Len(t, p2.List, 1) {
+		assert.Equal(t, all.List[1].Name, p2.List[0].Name)
+	}
+
+	p3 := contents("a", getBlogOption{Size: 1, Page: 3})
+	assert.Equal(t, 2, p3.Total)
+	assert.Len(t, p3.List, 0)
+}
+
+func TestPaginate(t *testing.T) {
+	b, err := NewHollow(Option{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ps := b.paginate(NewRenderContext())([]interface{}{1, 2, 3, 4, 5}, paginateOption{Size: 2, Path: "/posts/"})
+	if assert.Len(t, ps, 3) {
+		assert.Equal(t, Pagination{Page: 1, TotalPages: 3, Total: 5, List: []interface{}{1, 2}, Path: "posts", Next: "posts/page/2"}, ps[0])
+		assert.Equal(t, Pagination{Page: 2, TotalPages: 3, Total: 5, List: []interface{}{3, 4}, Path: "posts/page/2", Prev: "posts", Next: "posts/page/3"}, ps[1])
+		assert.Equal(t, Pagination{Page: 3, TotalPages: 3, Total: 5, List: []interface{}{5}, Path: "posts/page/3", Prev: "posts/page/2"}, ps[2])
+	}
+
+	ps = b.paginate(NewRenderContext())(nil, paginateOption{Path: "posts"})
+	if assert.Len(t, ps, 1) {
+		assert.Len(t, ps[0].List, 0)
+	}
+}
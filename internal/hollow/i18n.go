@@ -0,0 +1,243 @@
+package hollow
+
+import (
+	"errors"
+	"fmt"
+	"github.com/zbysir/hollow/internal/pkg/log"
+	"gopkg.in/yaml.v3"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+type ConfigLanguage struct {
+	Code string `json:"code" yaml:"code"` // 语言代码，如 zh、en，用于文件名 hello.en.md 与文件夹 contents/en
+	Name string `json:"name" yaml:"name"` // 显示名称，如 English
+}
+
+type ConfigLanguages struct {
+	Default string           `json:"default" yaml:"default"` // 默认语言，没有语言标记的内容属于默认语言，为空时使用 list 中的第一个
+	List    []ConfigLanguage `json:"list" yaml:"list"`
+	Dir     string           `json:"dir" yaml:"dir"` // 翻译文件所在文件夹，默认为 i18n，如 i18n/en.yml
+}
+
+func (c ConfigLanguages) defaultLang() string {
+	if c.Default != "" {
+		return c.Default
+	}
+	if len(c.List) != 0 {
+		return c.List[0].Code
+	}
+	return ""
+}
+
+func (c ConfigLanguages) has(code string) bool {
+	for _, l := range c.List {
+		if l.Code == code {
+			return true
+		}
+	}
+	return false
+}
+
+func (c ConfigLanguages) dir() string {
+	if c.Dir != "" {
+		return c.Dir
+	}
+	return "i18n"
+}
+
+// parseLang 返回文件的语言与翻译 key，同一内容的不同语言版本的 key 相同，如：
+// contents/hello.en.md => en, contents/hello
+// contents/en/hello.md => en, contents/hello
+// contents/hello.md => 默认语言, contents/hello
+// 没有配置 languages 时不会识别语言
+func (c ConfigLanguages) parseLang(file string) (lang string, key string) {
+	file = path.Clean(file)
+	key = strings.TrimSuffix(file, path.Ext(file))
+	if len(c.List) == 0 {
+		return "", key
+	}
+
+	dir, name := path.Split(key)
+	if ext := path.Ext(name); ext != "" && c.has(ext[1:]) {
+		lang = ext[1:]
+		name = strings.TrimSuffix(name, ext)
+	}
+
+	var segments []string
+	for _, s := range strings.Split(strings.Trim(dir, "/"), "/") {
+		if lang == "" && c.has(s) {
+			lang = s
+			continue
+		}
+		if s != "" {
+			segments = append(segments, s)
+		}
+	}
+	if lang == "" {
+		lang = c.defaultLang()
+	}
+	return lang, path.Join(append(segments, name)...)
+}
+
+type ContentTranslation struct {
+	Lang string `json:"lang"`
+	Path string `json:"path"` // 源文件路径，可用于 getContentDetail
+}
+
+// translationIndex 翻译 key => 语言 => 源文件路径
+type translationIndex map[string]map[string]string
+
+// translations 返回 file 的其他语言版本，按照配置的语言顺序排序
+func (t translationIndex) translations(conf ConfigLanguages, file string) []ContentTranslation {
+	lang, key := conf.parseLang(file)
+	var ts []ContentTranslation
+	for _, l := range conf.List {
+		p, ok := t[key][l.Code]
+		if ok && l.Code != lang {
+			ts = append(ts, ContentTranslation{Lang: l.Code, Path: p})
+		}
+	}
+	return ts
+}
+
+// translationIndex 返回 file 所在的顶层文件夹（如 contents）下所有内容的翻译索引
+func (b *Hollow) translationIndex(ctx *RenderContext, conf ConfigLanguages, file string) translationIndex {
+	root := strings.SplitN(path.Clean(file), "/", 2)[0]
+	// 翻译索引只依赖文件名，缓存命中时也需要记录依赖
+	ctx.dependOn(root)
+	cacheKey := "translationIndex:" + root
+	if x, ok := ctx.cache.Get(cacheKey); ok {
+		return x.(translationIndex)
+	}
+
+	index := translationIndex{}
+	err := fs.WalkDir(b.sourceStdFs, root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		if _, ok := b.getContentLoader(ctx, path.Ext(p)); !ok {
+			return nil
+		}
+		lang, key := conf.parseLang(p)
+		if index[key] == nil {
+			index[key] = map[string]string{}
+		}
+		index[key][lang] = p
+		return nil
+	})
+	if err != nil {
+		log.Warnf("walk translations of '%v' error: %v", root, err)
+	}
+	ctx.cache.Add(cacheKey, index)
+	return index
+}
+
+// withLang 设置内容的语言与其他语言版本，没有配置 languages 时不做处理
+func (b *Hollow) withLang(ctx *RenderContext, conf ConfigLanguages, file string, c Content) Content {
+	if len(conf.List) == 0 {
+		return c
+	}
+	lang, key := conf.parseLang(file)
+	c.Name = path.Base(key)
+	c.Lang = lang
+	for _, t := range b.translationIndex(ctx, conf, file).translations(conf, file) {
+		if b.translationVisible(ctx, t.Path) {
+			c.Translations = append(c.Translations, t)
+		}
+	}
+	return c
+}
+
+// translationVisible 返回其他语言版本是否需要显示，如草稿不会出现在正式构建的翻译链接中
+func (b *Hollow) translationVisible(ctx *RenderContext, file string) bool {
+	ctx.dependOn(file)
+	return b.contentVisible(ctx, b.tryReadMeta(file))
+}
+
+// translationTable 读取 lang 语言的翻译文件，支持 yml、yaml 与 json 格式
+func (b *Hollow) translationTable(ctx *RenderContext, conf ConfigLanguages, lang string) map[string]interface{} {
+	var files []string
+	for _, ext := range []string{".yml", ".yaml", ".json"} {
+		files = append(files, path.Join(conf.dir(), lang+ext))
+	}
+	ctx.dependOn(files...)
+	cacheKey := "translationTable:" + lang
+	if x, ok := ctx.cache.Get(cacheKey); ok {
+		return x.(map[string]interface{})
+	}
+
+	table := map[string]interface{}{}
+	for _, file := range files {
+		bs, err := fs.ReadFile(b.sourceStdFs, file)
+		if err != nil {
+			if !errors.Is(err, fs.ErrNotExist) {
+				log.Warnf("read translation file '%v' error: %v", file, err)
+			}
+			continue
+		}
+		err = yaml.Unmarshal(bs, &table)
+		if err != nil {
+			log.Warnf("unmarshal translation file '%v' error: %v", file, err)
+		}
+		break
+	}
+	ctx.cache.Add(cacheKey, table)
+	return table
+}
+
+// translate 在翻译表中查找 key，支持使用 . 访问嵌套的 key，如 nav.home
+func translate(table map[string]interface{}, key string) (string, bool) {
+	if v, ok := table[key]; ok {
+		return fmt.Sprint(v), true
+	}
+	v, ok := lookupMapI(table, strings.Split(key, ".")...)
+	if !ok {
+		return "", false
+	}
+	if _, isMap := v.(map[string]interface{}); isMap {
+		return "", false
+	}
+	return fmt.Sprint(v), true
+}
+
+// t 返回 key 在 lang 语言下的翻译，lang 为空时使用默认语言，找不到时依次使用默认语言的翻译与 key 本身，如：
+// t('read_more', 'en')
+func (b *Hollow) t(ctx *RenderContext) func(key string, lang string) string {
+	return func(key string, lang string) string {
+		c, err := b.LoadConfig(ctx)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			log.Warnf("LoadConfig for t error: %v", err)
+		}
+		conf := c.Hollow.Languages
+		if lang == "" {
+			lang = conf.defaultLang()
+		}
+		for _, l := range []string{lang, conf.defaultLang()} {
+			if l == "" {
+				continue
+			}
+			if s, ok := translate(b.translationTable(ctx, conf, l), key); ok {
+				return s
+			}
+		}
+		return key
+	}
+}
+
+// getLanguages 返回配置的语言，用于生成不同语言的页面与语言切换链接
+func (b *Hollow) getLanguages(ctx *RenderContext) func() ConfigLanguages {
+	return func() ConfigLanguages {
+		c, err := b.LoadConfig(ctx)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			log.Warnf("LoadConfig for getLanguages error: %v", err)
+		}
+		conf := c.Hollow.Languages
+		conf.Default = conf.defaultLang()
+		return conf
+	}
+}
@@ -0,0 +1,89 @@
+package hollow
+
+import (
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/go-git/go-billy/v5/util"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestParseLang(t *testing.T) {
+	conf := ConfigLanguages{List: []ConfigLanguage{{Code: "zh"}, {Code: "en"}}}
+	for file, want := range map[string][2]string{
+		"contents/hello.md":       {"zh", "contents/hello"},
+		"contents/hello.en.md":    {"en", "contents/hello"},
+		"contents/en/hello.md":    {"en", "contents/hello"},
+		"./contents/zh/a/b.mdx":   {"zh", "contents/a/b"},
+		"contents/v1.2.md":        {"zh", "contents/v1.2"},
+		"contents/en/hello.zh.md": {"zh", "contents/en/hello"},
+	} {
+		lang, key := conf.parseLang(file)
+		assert.Equal(t, want, [2]string{lang, key}, file)
+	}
+
+	lang, key := ConfigLanguages{}.parseLang("contents/hello.en.md")
+	assert.Equal(t, "", lang)
+	assert.Equal(t, "contents/hello.en", key)
+}
+
+func TestI18n(t *testing.T) {
+	source := memfs.New()
+	writeTestFile(t, source, "config.yml", `
+theme: theme
+languages:
+  default: zh
+  list:
+    - code: zh
+      name: 中文
+    - code: en
+      name: English
+`)
+	writeTestFile(t, source, "contents/hello.md", "---\ntitle: 你好\n---\n你好")
+	writeTestFile(t, source, "contents/hello.en.md", "---\ntitle: Hello\n---\nhello")
+	writeTestFile(t, source, "contents/en/about.md", "---\ntitle: About\n---\nabout")
+	writeTestFile(t, source, "i18n/zh.yml", "read_more: 阅读更多\nnav:\n  home: 首页\n")
+	writeTestFile(t, source, "i18n/en.yml", "read_more: Read more\n")
+	writeTestFile(t, source, "theme/index.jsx", `
+import {getContents, getContentDetail, getLanguages, t} from "@bysir/hollow"
+
+const pages = getLanguages().list.map(l => ({
+  path: l.code === getLanguages().default ? "" : l.code,
+  component: () => {
+    const list = getContents("contents", {lang: l.code, sort: (a, b) => a.name < b.name}).list
+    return <ul>{list.map(c => <li>{c.name}:{c.lang}:{c.meta.title}:{c.translations.map(t => t.lang + "=" + t.path).join(",")}</li>)}</ul>
+  }
+}))
+
+export default {
+  pages: [
+    ...pages,
+    {path: "detail", component: () => <p>{getContentDetail("contents/hello.md", {lang: "en"}).meta.title}</p>},
+    {path: "t", component: () => <p>{t("read_more")},{t("read_more", "en")},{t("nav.home", "en")},{t("missing", "en")}</p>},
+  ],
+  assets: [],
+}
+`)
+
+	b, err := NewHollow(Option{SourceFs: source})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dst := memfs.New()
+	err = b.BuildToFs(NewRenderContext(), dst, ExecOption{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, body := range map[string]string{
+		"index.html":        "<ul><li>hello:zh:你好:en=contents/hello.en.md</li></ul>",
+		"en/index.html":     "<ul><li>about:en:About:</li><li>hello:en:Hello:zh=contents/hello.md</li></ul>",
+		"detail/index.html": "<p>Hello</p>",
+		"t/index.html":      "<p>阅读更多,Read more,首页,missing</p>",
+	} {
+		bs, err := util.ReadFile(dst, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, body, string(bs), name)
+	}
+}
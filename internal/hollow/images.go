@@ -0,0 +1,496 @@
+package hollow
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/go-git/go-billy/v5"
+	"github.com/go-git/go-billy/v5/util"
+	jsx "github.com/zbysir/gojsx"
+	himage "github.com/zbysir/hollow/internal/pkg/image"
+	"github.com/zbysir/hollow/internal/pkg/log"
+	"go.uber.org/zap"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ConfigImages 配置内容中图片的响应式处理，构建时会为图片生成多个宽度与格式的版本
+type ConfigImages struct {
+	Enable  bool     `json:"enable" yaml:"enable"`
+	Widths  []int    `json:"widths" yaml:"widths"`   // 生成的宽度，默认为 480、960、1600，大于原图的宽度会被忽略
+	Formats []string `json:"formats" yaml:"formats"` // 生成的格式，默认为 webp 与 jpeg，浏览器会使用第一个支持的格式
+	Quality int      `json:"quality" yaml:"quality"` // jpeg 的质量，默认为 80，webp 使用无损编码
+	Sizes   string   `json:"sizes" yaml:"sizes"`     // img 的 sizes 属性，默认为 100vw
+}
+
+func (c ConfigImages) widths() []int {
+	if len(c.Widths) == 0 {
+		return []int{480, 960, 1600}
+	}
+	return c.Widths
+}
+
+func (c ConfigImages) quality() int {
+	if c.Quality <= 0 || c.Quality > 100 {
+		return 80
+	}
+	return c.Quality
+}
+
+func (c ConfigImages) sizes() string {
+	if c.Sizes == "" {
+		return "100vw"
+	}
+	return c.Sizes
+}
+
+// formats 返回生成的格式
+func (c ConfigImages) formats() []string {
+	list := c.Formats
+	if len(list) == 0 {
+		list = []string{"webp", "jpeg"}
+	}
+	var r []string
+	for _, f := range list {
+		f = strings.ToLower(f)
+		if f == "jpg" {
+			f = "jpeg"
+		}
+		r = append(r, f)
+	}
+	return r
+}
+
+const imageVariantPrefix = "__image"
+
+var imageMimeTypes = map[string]string{
+	"webp": "image/webp",
+	"jpeg": "image/jpeg",
+	"png":  "image/png",
+}
+
+// imageVariant 是图片生成的一个版本，路径中包含源文件的 hash，图片变化后地址也会变化：
+// __image/{hash}/{spec}/{source}.{format}，如 __image/0123456789abcdef/480/contents/img/a.jpg.webp
+//
+// spec 为 {width}、{width}x{height}-{fit}，指定了质量时再加上 -q{quality}，如 480x320-cover-q60
+type imageVariant struct {
+	Source  string // 源文件路径，如 contents/img/a.jpg，source 中不存在时为主题中的文件
+	Hash    string
+	Width   int
+	Height  int    // 为 0 时按照宽度等比缩放
+	Fit     string // 同时指定了宽高时的裁剪方式
+	Quality int    // 为 0 时使用配置中的质量
+	Format  string
+}
+
+func (v imageVariant) size() string {
+	s := strconv.Itoa(v.Width)
+	if v.Height > 0 {
+		s += "x" + strconv.Itoa(v.Height)
+		if v.Fit != "" {
+			s += "-" + v.Fit
+		}
+	}
+	return s
+}
+
+func (v imageVariant) spec() string {
+	if v.Quality > 0 {
+		return v.size() + "-q" + strconv.Itoa(v.Quality)
+	}
+	return v.size()
+}
+
+func (v imageVariant) quality(def int) int {
+	if v.Quality > 0 {
+		return v.Quality
+	}
+	return def
+}
+
+func (v imageVariant) transform() himage.TransformOption {
+	return himage.TransformOption{Width: v.Width, Height: v.Height, Fit: v.Fit}
+}
+
+func (v imageVariant) path() string {
+	return path.Join(imageVariantPrefix, v.Hash, v.spec(), v.Source+"."+v.Format)
+}
+
+// cacheFile 是图片在 CacheFs 中的路径，源文件与参数不变时不会重新编码
+func (v imageVariant) cacheFile(quality int) string {
+	return path.Join("images", fmt.Sprintf("%v-%v-q%v.%v", v.Hash, v.size(), v.quality(quality), v.Format))
+}
+
+// parseImageVariant 解析 imageVariant.path 生成的路径
+func parseImageVariant(p string) (imageVariant, bool) {
+	ps := strings.SplitN(strings.TrimPrefix(p, "/"), "/", 4)
+	if len(ps) != 4 || ps[0] != imageVariantPrefix {
+		return imageVariant{}, false
+	}
+	v, ok := parseImageSpec(ps[2])
+	if !ok {
+		return imageVariant{}, false
+	}
+	format := strings.TrimPrefix(path.Ext(ps[3]), ".")
+	if _, ok := imageMimeTypes[format]; !ok {
+		return imageVariant{}, false
+	}
+	v.Source = strings.TrimSuffix(ps[3], "."+format)
+	v.Hash = ps[1]
+	v.Format = format
+	return v, true
+}
+
+func parseImageSpec(spec string) (v imageVariant, ok bool) {
+	parts := strings.Split(spec, "-")
+	size := strings.SplitN(parts[0], "x", 2)
+	var err error
+	if v.Width, err = strconv.Atoi(size[0]); err != nil || v.Width < 0 {
+		return v, false
+	}
+	if len(size) == 2 {
+		if v.Height, err = strconv.Atoi(size[1]); err != nil || v.Height < 0 {
+			return v, false
+		}
+	}
+	if v.Width == 0 && v.Height == 0 {
+		return v, false
+	}
+
+	for _, s := range parts[1:] {
+		switch {
+		case isImageFit(s) && v.Height > 0 && v.Fit == "":
+			v.Fit = s
+		case strings.HasPrefix(s, "q") && v.Quality == 0:
+			if v.Quality, err = strconv.Atoi(s[1:]); err != nil || v.Quality <= 0 || v.Quality > 100 {
+				return v, false
+			}
+		default:
+			return v, false
+		}
+	}
+	return v, true
+}
+
+func isImageFit(fit string) bool {
+	switch fit {
+	case himage.FitCover, himage.FitContain, himage.FitFill:
+		return true
+	}
+	return false
+}
+
+func isResponsiveImage(file string) bool {
+	return imageFormatOf(file) != ""
+}
+
+// imageFormatOf 返回可以处理的图片文件的格式，不支持时返回空字符串
+func imageFormatOf(file string) string {
+	switch strings.ToLower(path.Ext(file)) {
+	case ".jpg", ".jpeg":
+		return "jpeg"
+	case ".png":
+		return "png"
+	}
+	return ""
+}
+
+// readImageSource 读取图片文件，source 中不存在时读取主题中的文件
+func (b *Hollow) readImageSource(themeFs fs.FS, p string) ([]byte, error) {
+	bs, err := fs.ReadFile(b.sourceStdFs, p)
+	if err == nil || !errors.Is(err, fs.ErrNotExist) || themeFs == nil {
+		return bs, err
+	}
+	return fs.ReadFile(themeFs, p)
+}
+
+type imageInfo struct {
+	Hash   string
+	Width  int
+	Height int
+}
+
+// useImageVariant 记录需要在构建时生成的图片版本
+func (b *RenderContext) useImageVariant(v imageVariant) {
+	b.Save("images", v)
+	b.produce(v.path())
+}
+
+// imagePipeline 为内容中的图片添加 srcset，并记录需要生成的图片版本
+type imagePipeline struct {
+	b    *Hollow
+	ctx  *RenderContext
+	conf ConfigImages
+}
+
+// imagePipeline 没有开启图片处理时返回 nil
+func (b *Hollow) imagePipeline(ctx *RenderContext, conf HollowConfig) *imagePipeline {
+	if !conf.Images.Enable {
+		return nil
+	}
+	return &imagePipeline{b: b, ctx: ctx, conf: conf.Images}
+}
+
+func (b *Hollow) imageInfo(ctx *RenderContext, source string) (imageInfo, error) {
+	cacheKey := "imageInfo:" + source
+	if x, ok := ctx.cache.Get(cacheKey); ok {
+		return x.(imageInfo), nil
+	}
+
+	bs, err := b.readImageSource(ctx.themeFs, source)
+	if err != nil {
+		return imageInfo{}, err
+	}
+	c, _, err := himage.DecodeConfig(bytes.NewReader(bs))
+	if err != nil {
+		return imageInfo{}, fmt.Errorf("decode image '%v' error: %w", source, err)
+	}
+	sum := sha256.Sum256(bs)
+	info := imageInfo{Hash: hex.EncodeToString(sum[:8]), Width: c.Width, Height: c.Height}
+	ctx.cache.Add(cacheKey, info)
+	return info, nil
+}
+
+// variantWidths 返回小于原图宽度的配置宽度，以及原图宽度
+func variantWidths(widths []int, max int) []int {
+	var ws []int
+	for _, w := range widths {
+		if w > 0 && w < max {
+			ws = append(ws, w)
+		}
+	}
+	sort.Ints(ws)
+	return append(ws, max)
+}
+
+// rewrite 为 /__source 下的 jpeg 与 png 图片添加 srcset、sizes、width、height 与 loading="lazy"，
+// 配置了多个格式时使用 <picture> 包裹，最后一个格式作为 img 的 srcset
+func (p *imagePipeline) rewrite(dom jsx.VDom) {
+	if p == nil {
+		return
+	}
+	formats := p.conf.formats()
+	if len(formats) == 0 {
+		return
+	}
+
+	walkVDom(dom, func(d jsx.VDom) {
+		if d["nodeName"] != "img" {
+			return
+		}
+		attr := d["attributes"].(map[string]interface{})
+		src, _ := attr["src"].(string)
+		if _, ok := attr["srcset"]; ok || !strings.HasPrefix(src, "/__source/") || !isResponsiveImage(src) {
+			return
+		}
+		source := strings.TrimPrefix(src, "/__source/")
+		p.ctx.dependOn(source)
+		info, err := p.b.imageInfo(p.ctx, source)
+		if err != nil {
+			log.Warnf("responsive image '%v' error: %v", source, err)
+			return
+		}
+
+		srcsets := make([]string, len(formats))
+		for i, f := range formats {
+			var set []string
+			for _, w := range variantWidths(p.conf.widths(), info.Width) {
+				v := imageVariant{Source: source, Hash: info.Hash, Width: w, Format: f}
+				p.ctx.useImageVariant(v)
+				set = append(set, fmt.Sprintf("/%v %vw", v.path(), w))
+			}
+			srcsets[i] = strings.Join(set, ", ")
+		}
+
+		img := map[string]interface{}{}
+		for k, v := range attr {
+			img[k] = v
+		}
+		img["srcset"] = srcsets[len(srcsets)-1]
+		img["sizes"] = p.conf.sizes()
+		if _, ok := img["width"]; !ok {
+			if _, ok := img["height"]; !ok {
+				img["width"] = strconv.Itoa(info.Width)
+				img["height"] = strconv.Itoa(info.Height)
+			}
+		}
+		if _, ok := img["loading"]; !ok {
+			img["loading"] = "lazy"
+		}
+
+		if len(formats) == 1 {
+			d["attributes"] = img
+			return
+		}
+		var children []interface{}
+		for i, f := range formats[:len(formats)-1] {
+			children = append(children, jsx.VDom{"nodeName": "source", "attributes": map[string]interface{}{
+				"type":   imageMimeTypes[f],
+				"srcset": srcsets[i],
+				"sizes":  p.conf.sizes(),
+			}})
+		}
+		children = append(children, jsx.VDom{"nodeName": "img", "attributes": img})
+		d["nodeName"] = "picture"
+		d["attributes"] = map[string]interface{}{"children": children}
+	})
+}
+
+// imageVariantBytes 返回图片版本的内容，优先读取 CacheFs 中的缓存，quality 为默认的质量
+func (b *Hollow) imageVariantBytes(themeFs fs.FS, v imageVariant, quality int) ([]byte, error) {
+	cacheFile := v.cacheFile(quality)
+	b.cacheLock.RLock()
+	bs, err := util.ReadFile(b.CacheFs, cacheFile)
+	b.cacheLock.RUnlock()
+	if err == nil {
+		return bs, nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	bs, err = b.readImageSource(themeFs, v.Source)
+	if err != nil {
+		return nil, err
+	}
+	img, _, err := himage.Decode(bytes.NewReader(bs))
+	if err != nil {
+		return nil, fmt.Errorf("decode image '%v' error: %w", v.Source, err)
+	}
+	var buf bytes.Buffer
+	err = himage.Encode(&buf, himage.Transform(img, v.transform()), v.Format, v.quality(quality))
+	if err != nil {
+		return nil, fmt.Errorf("encode image '%v' error: %w", v.path(), err)
+	}
+
+	b.cacheLock.Lock()
+	err = util.WriteFile(b.CacheFs, cacheFile, buf.Bytes(), 0644)
+	b.cacheLock.Unlock()
+	if err != nil {
+		log.Warnf("cache image '%v' error: %v", cacheFile, err)
+	}
+	return buf.Bytes(), nil
+}
+
+// buildImages 生成渲染过程中记录的所有图片版本
+func (b *Hollow) buildImages(ctx *RenderContext, conf ConfigImages, dst billy.Filesystem, l *zap.SugaredLogger) error {
+	vs := map[string]imageVariant{}
+	for _, i := range ctx.GetData("images") {
+		v := i.(imageVariant)
+		vs[v.path()] = v
+	}
+	if len(vs) == 0 {
+		return nil
+	}
+
+	var wg sync.WaitGroup
+	var lock sync.Mutex
+	var errs []error
+	c := make(chan bool, 4)
+	for p, v := range vs {
+		wg.Add(1)
+		c <- true
+		go func(p string, v imageVariant) {
+			defer func() {
+				<-c
+				wg.Done()
+			}()
+
+			bs, err := b.imageVariantBytes(ctx.themeFs, v, conf.quality())
+			lock.Lock()
+			defer lock.Unlock()
+			if err == nil {
+				// dst 不一定支持并发写入
+				err = writeFile(dst, p, bs)
+			}
+			if err != nil {
+				errs = append(errs, err)
+			}
+		}(p, v)
+	}
+	wg.Wait()
+
+	if len(errs) != 0 {
+		return errs[0]
+	}
+	l.Infof("Create images: %v", len(vs))
+	return nil
+}
+
+type imageOption struct {
+	Width   int    `json:"width"`
+	Height  int    `json:"height"`
+	Fit     string `json:"fit"`     // 同时指定宽高时的裁剪方式：cover、contain 或 fill，默认为 cover
+	Format  string `json:"format"`  // jpeg、png 或 webp，默认与原图一致
+	Quality int    `json:"quality"` // 有损格式的质量，默认为配置中的 images.quality
+}
+
+type imageResult struct {
+	Url    string `json:"url"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+}
+
+// image 缩放或裁剪 source 或主题中的图片，返回生成的图片地址与尺寸，图片会在构建时生成，找不到图片时返回 null
+func (b *Hollow) image(ctx *RenderContext) func(p string, opt imageOption) *imageResult {
+	return func(p string, opt imageOption) *imageResult {
+		source := strings.TrimPrefix(path.Clean("/"+p), "/")
+		source = strings.TrimPrefix(source, "__source/")
+
+		format := imageFormatOf(source)
+		if format == "" {
+			log.Warnf("image '%v' error: only jpeg and png images are supported", p)
+			return nil
+		}
+		if opt.Format != "" {
+			list := ConfigImages{Formats: []string{opt.Format}}.formats()
+			if len(list) == 1 {
+				format = list[0]
+			}
+			if _, ok := imageMimeTypes[format]; !ok {
+				log.Warnf("image '%v' error: unsupported format '%v'", p, opt.Format)
+				return nil
+			}
+		}
+
+		ctx.dependOn(source)
+		info, err := b.imageInfo(ctx, source)
+		if err != nil {
+			if !errors.Is(err, fs.ErrNotExist) {
+				log.Warnf("image '%v' error: %v", p, err)
+			}
+			return nil
+		}
+
+		v := imageVariant{Source: source, Hash: info.Hash, Format: format}
+		if opt.Width > 0 {
+			v.Width = opt.Width
+		}
+		if opt.Height > 0 {
+			v.Height = opt.Height
+		}
+		if v.Width == 0 && v.Height == 0 {
+			v.Width = info.Width
+		}
+		if v.Width > 0 && v.Height > 0 {
+			v.Fit = himage.FitCover
+			if isImageFit(opt.Fit) {
+				v.Fit = opt.Fit
+			}
+		}
+		if opt.Quality > 0 && opt.Quality <= 100 {
+			v.Quality = opt.Quality
+		}
+
+		_, _, w, h := v.transform().Size(info.Width, info.Height)
+		ctx.useImageVariant(v)
+		return &imageResult{Url: "/" + v.path(), Width: w, Height: h}
+	}
+}
@@ -0,0 +1,199 @@
+package hollow
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/zbysir/hollow/internal/pkg/gobilly"
+	"html"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io/fs"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestParseImageVariant(t *testing.T) {
+	v := imageVariant{Source: "contents/img/a.png", Hash: "0123456789abcdef", Width: 480, Format: "webp"}
+	assert.Equal(t, "__image/0123456789abcdef/480/contents/img/a.png.webp", v.path())
+
+	p, ok := parseImageVariant("/" + v.path())
+	assert.True(t, ok)
+	assert.Equal(t, v, p)
+
+	for _, s := range []string{"contents/a.png", "__image/x/abc/a.png.webp", "__image/x/480/a.png.gif"} {
+		_, ok = parseImageVariant(s)
+		assert.False(t, ok, s)
+	}
+
+	v = imageVariant{Source: "theme/cover.jpg", Hash: "0123456789abcdef", Width: 300, Height: 200, Fit: "contain", Quality: 60, Format: "jpeg"}
+	assert.Equal(t, "__image/0123456789abcdef/300x200-contain-q60/theme/cover.jpg.jpeg", v.path())
+	assert.Equal(t, "images/0123456789abcdef-300x200-contain-q60.jpeg", v.cacheFile(80))
+	p, ok = parseImageVariant(v.path())
+	assert.True(t, ok)
+	assert.Equal(t, v, p)
+
+	p, ok = parseImageVariant("__image/x/0x200/a.png.png")
+	assert.True(t, ok)
+	assert.Equal(t, imageVariant{Source: "a.png", Hash: "x", Height: 200, Format: "png"}, p)
+
+	for _, s := range []string{"0", "0x0", "300-cover", "300x200-crop", "300x200-q0", "300x200-q101"} {
+		_, ok = parseImageSpec(s)
+		assert.False(t, ok, s)
+	}
+
+	assert.Equal(t, []int{10, 30, 40}, variantWidths([]int{30, 10, 100}, 40))
+}
+
+func testPng(t *testing.T, w, h int) string {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for x := 0; x < w; x++ {
+		for y := 0; y < h; y++ {
+			img.Set(x, y, color.RGBA{R: uint8(x * 6), G: uint8(y * 12), A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+func TestBuildImages(t *testing.T) {
+	b, _ := newTestSite(t, map[string]string{
+		"config.yml":       "theme: theme\nimages:\n  enable: true\n  widths: [10, 100]\n  formats: [jpg, png]\n  sizes: 50vw\n",
+		"contents/a.md":    "![图片](./img.png)\n\n![远程](https://example.com/a.png)",
+		"contents/img.png": testPng(t, 40, 20),
+		"theme/index.jsx": `
+import {getContents} from "@bysir/hollow"
+
+export default {
+  pages: [{path: "", component: () => <div>{getContents("contents").list.map(c => c.content)}</div>}],
+  assets: [],
+}
+`,
+	})
+	dst := buildTestSite(t, b, ExecOption{})
+
+	info, err := b.imageInfo(NewRenderContext(), "contents/img.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 40, info.Width)
+	assert.Equal(t, 20, info.Height)
+
+	variant := func(w int, format string) string {
+		return imageVariant{Source: "contents/img.png", Hash: info.Hash, Width: w, Format: format}.path()
+	}
+	assert.Equal(t, fmt.Sprintf(`<div><p><picture>`+
+		`<source sizes="50vw" srcset="/%v 10w, /%v 40w" type="image/jpeg"/>`+
+		`<img alt="图片" height="20" loading="lazy" sizes="50vw" src="/__source/contents/img.png" srcset="/%v 10w, /%v 40w" width="40"/>`+
+		`</picture></p><p><img alt="远程" src="https://example.com/a.png"/></p></div>`,
+		variant(10, "jpeg"), variant(40, "jpeg"), variant(10, "png"), variant(40, "png")), html.UnescapeString(readTestFile(t, dst, "index.html")))
+
+	c, err := jpeg.DecodeConfig(strings.NewReader(readTestFile(t, dst, variant(10, "jpeg"))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 10, c.Width)
+	assert.Equal(t, 5, c.Height)
+
+	c, err = png.DecodeConfig(strings.NewReader(readTestFile(t, dst, variant(40, "png"))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 40, c.Width)
+
+	// 编码结果缓存在 CacheFs 中，再次构建时直接读取
+	cached := imageVariant{Source: "contents/img.png", Hash: info.Hash, Width: 10, Format: "jpeg"}.cacheFile(80)
+	writeTestFile(t, b.CacheFs, cached, "cached")
+	dst = buildTestSite(t, b, ExecOption{})
+	assert.Equal(t, "cached", readTestFile(t, dst, variant(10, "jpeg")))
+}
+
+func TestImage(t *testing.T) {
+	b, f := newTestSite(t, map[string]string{
+		"config.yml":       "theme: theme\n",
+		"contents/img.png": testPng(t, 40, 20),
+		"theme/cover.png":  testPng(t, 30, 30),
+		"theme/index.jsx": `
+import {image} from "@bysir/hollow"
+
+function Img({src, ...opts}) {
+  const i = image(src, opts)
+  return i ? <img src={i.url} width={i.width} height={i.height}/> : <span>none</span>
+}
+
+export default {
+  pages: [{
+    path: "", component: () => <div>
+      <Img src="contents/img.png" width={20}/>
+      <Img src="/__source/contents/img.png" width={10} height={10} format="jpg" quality={60}/>
+      <Img src="cover.png" height={15}/>
+      <Img src="contents/img.png" width={10} format="webp"/>
+      <Img src="missing.png"/>
+    </div>
+  }],
+  assets: [],
+}
+`,
+	})
+	dst := buildTestSite(t, b, ExecOption{})
+
+	var err error
+	ctx := NewRenderContext()
+	ctx.themeFs, err = fs.Sub(gobilly.NewStdFs(f), "theme")
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := b.imageInfo(ctx, "contents/img.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cover, err := b.imageInfo(ctx, "cover.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a := imageVariant{Source: "contents/img.png", Hash: img.Hash, Width: 20, Format: "png"}.path()
+	c := imageVariant{Source: "contents/img.png", Hash: img.Hash, Width: 10, Height: 10, Fit: "cover", Quality: 60, Format: "jpeg"}.path()
+	d := imageVariant{Source: "cover.png", Hash: cover.Hash, Height: 15, Format: "png"}.path()
+	e := imageVariant{Source: "contents/img.png", Hash: img.Hash, Width: 10, Format: "webp"}.path()
+	assert.Equal(t, fmt.Sprintf(`<div><img height="10" src="/%v" width="20"/><img height="10" src="/%v" width="10"/><img height="15" src="/%v" width="15"/><img height="5" src="/%v" width="10"/><span>none</span></div>`, a, c, d, e), readTestFile(t, dst, "index.html"))
+	// webp 不依赖外部命令
+	assert.True(t, strings.HasPrefix(readTestFile(t, dst, e), "RIFF"), e)
+
+	for _, p := range []string{a, c, d} {
+		conf, _, err := image.DecodeConfig(strings.NewReader(readTestFile(t, dst, p)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		v, _ := parseImageVariant(p)
+		_, _, w, h := v.transform().Size(40, 20)
+		if v.Source == "cover.png" {
+			_, _, w, h = v.transform().Size(30, 30)
+		}
+		assert.Equal(t, [2]int{w, h}, [2]int{conf.Width, conf.Height}, p)
+	}
+
+	// 开发服务中按需生成
+	handle := b.ServiceHandle(ExecOption{IsDev: true})
+	w := httptest.NewRecorder()
+	handle(w, httptest.NewRequest("GET", "/"+d, nil))
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, "image/png", w.Header().Get("Content-Type"))
+	assert.Equal(t, readTestFile(t, dst, d), w.Body.String())
+
+	// hash 与源文件不一致或者源文件不存在时返回 404
+	for _, v := range []imageVariant{
+		{Source: "cover.png", Hash: "0123456789abcdef", Height: 15, Format: "png"},
+		{Source: "contents/none.png", Hash: cover.Hash, Height: 15, Format: "png"},
+	} {
+		w = httptest.NewRecorder()
+		handle(w, httptest.NewRequest("GET", "/"+v.path(), nil))
+		assert.Equal(t, 404, w.Code, v.path())
+	}
+}
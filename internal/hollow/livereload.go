@@ -0,0 +1,178 @@
+package hollow
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/fsnotify/fsnotify"
+	"github.com/gorilla/websocket"
+	"github.com/zbysir/hollow/internal/pkg/log"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// liveReloadKeyPrefix 是浏览器连接 /_dev_/ws/:key 时使用的 key 前缀，每个页面使用不同的 key
+const liveReloadKeyPrefix = "livereload-"
+
+// liveReloadDelay 文件变化后等待的时间，合并短时间内的多次变化（如编辑器保存、git checkout）
+const liveReloadDelay = 100 * time.Millisecond
+
+// liveReloadScript 注入到 dev server 渲染的 html 页面中，收到消息后刷新页面，css 文件变化时只替换样式
+const liveReloadScript = `<script>
+(function () {
+  var key = "` + liveReloadKeyPrefix + `" + Math.random().toString(36).slice(2);
+  function connect() {
+    var ws = new WebSocket((location.protocol === "https:" ? "wss://" : "ws://") + location.host + "/_dev_/ws/" + key);
+    ws.onmessage = function (e) {
+      if (!e.data) return;
+      var msg = JSON.parse(e.data);
+      if (msg.type !== "css") {
+        location.reload();
+        return;
+      }
+      document.querySelectorAll('link[rel="stylesheet"]').forEach(function (l) {
+        var url = new URL(l.href);
+        url.searchParams.set("_t", Date.now());
+        l.href = url.toString();
+      });
+    };
+    ws.onclose = function () {
+      setTimeout(connect, 1000);
+    };
+  }
+  connect();
+})();
+</script>`
+
+// liveReloadEvent 是发送给浏览器的消息，type 为 reload 或者 css
+type liveReloadEvent struct {
+	Type  string   `json:"type"`
+	Files []string `json:"files"`
+}
+
+// injectLiveReload 将 liveReloadScript 插入到 html 文档的 </body> 或者 </html> 之前，非完整的 html 文档则不处理
+func injectLiveReload(body string) string {
+	for _, tag := range []string{"</body>", "</html>"} {
+		if i := strings.LastIndex(body, tag); i != -1 {
+			return body[:i] + liveReloadScript + body[i:]
+		}
+	}
+	return body
+}
+
+// newLiveReloadEvent 根据变化的文件生成消息，只有 css 文件变化时不需要刷新页面
+func newLiveReloadEvent(files []string) liveReloadEvent {
+	t := "css"
+	for _, f := range files {
+		if filepath.Ext(f) != ".css" {
+			t = "reload"
+			break
+		}
+	}
+	return liveReloadEvent{Type: t, Files: files}
+}
+
+// skipWatch 返回是否忽略该文件夹，如 .git 与 node_modules
+func skipWatch(name string) bool {
+	return strings.HasPrefix(name, ".") || name == "node_modules"
+}
+
+// watchDir 递归监听 dir 下的所有文件夹
+func watchDir(w *fsnotify.Watcher, dir string) error {
+	return filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if p != dir && skipWatch(d.Name()) {
+			return filepath.SkipDir
+		}
+		return w.Add(p)
+	})
+}
+
+// Watch 监听源文件与本地主题文件夹，文件变化时通知所有打开的页面刷新，直到 ctx 结束
+func (b *Hollow) Watch(ctx context.Context) error {
+	conf, err := b.LookupConfig(NewRenderContext())
+	if err != nil {
+		return err
+	}
+
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+
+	for _, dir := range append([]string{conf.SourcePath}, conf.ThemePaths...) {
+		if dir == "" {
+			continue
+		}
+		if err = watchDir(w, dir); err != nil {
+			return fmt.Errorf("watch '%v' error: %w", dir, err)
+		}
+		log.Infof("Watching %v", dir)
+	}
+
+	changed := map[string]struct{}{}
+	timer := time.NewTimer(liveReloadDelay)
+	timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-w.Errors:
+			log.Warnf("watch error: %v", err)
+		case e := <-w.Events:
+			if e.Op == fsnotify.Chmod || skipWatch(filepath.Base(e.Name)) {
+				continue
+			}
+			// 新建的文件夹也需要监听
+			if e.Op&fsnotify.Create != 0 {
+				if info, err := os.Stat(e.Name); err == nil && info.IsDir() {
+					if err = watchDir(w, e.Name); err != nil {
+						log.Warnf("watch '%v' error: %v", e.Name, err)
+					}
+				}
+			}
+			changed[e.Name] = struct{}{}
+			timer.Reset(liveReloadDelay)
+		case <-timer.C:
+			files := make([]string, 0, len(changed))
+			for f := range changed {
+				files = append(files, f)
+			}
+			sort.Strings(files)
+			changed = map[string]struct{}{}
+
+			bs, _ := json.Marshal(newLiveReloadEvent(files))
+			log.Infof("Reload: %v", strings.Join(files, ", "))
+			b.wsHub.Broadcast(liveReloadKeyPrefix, bs)
+		}
+	}
+}
+
+// serveWs 将浏览器的连接加入 wsHub，并一直读取直到连接关闭，关闭后从 wsHub 中移除，避免关闭的页面一直占用连接
+func (b *Hollow) serveWs(key string, conn *websocket.Conn) {
+	b.wsHub.Add(key, conn)
+	defer b.wsHub.Remove(key, conn)
+	defer conn.Close()
+
+	for {
+		if _, _, err := conn.ReadMessage(); err != nil {
+			return
+		}
+	}
+}
+
+// isHtmlPath 返回页面路径是否会输出为 html 文件，如 posts/a、index.html
+func isHtmlPath(p string) bool {
+	ext := filepath.Ext(p)
+	return ext == "" || ext == ".html"
+}
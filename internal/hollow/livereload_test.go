@@ -0,0 +1,136 @@
+package hollow
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/go-git/go-billy/v5/osfs"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestInjectLiveReload(t *testing.T) {
+	source := memfs.New()
+	writeTestFile(t, source, "config.yml", "theme: theme\n")
+	writeTestFile(t, source, "theme/index.jsx", `
+export default {
+  pages: [
+    {path: "", component: () => <html><body><div>home</div></body></html>},
+    {path: "part", component: () => <div>part</div>},
+  ],
+  assets: [],
+}
+`)
+	b, err := NewHollow(Option{SourceFs: source})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	handle := b.ServiceHandle(ExecOption{IsDev: true, LiveReload: true})
+	w := httptest.NewRecorder()
+	handle(w, httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, "<!DOCTYPE html><html><body><div>home</div>"+liveReloadScript+"</body></html>", w.Body.String())
+
+	w = httptest.NewRecorder()
+	handle(w, httptest.NewRequest("GET", "/part", nil))
+	assert.Equal(t, "<div>part</div>", w.Body.String())
+
+	// 没有开启 LiveReload 时不注入，如 hollow api 中的预览
+	for _, o := range []ExecOption{{}, {IsDev: true}} {
+		handle = b.ServiceHandle(o)
+		w = httptest.NewRecorder()
+		handle(w, httptest.NewRequest("GET", "/", nil))
+		assert.Equal(t, "<!DOCTYPE html><html><body><div>home</div></body></html>", w.Body.String())
+	}
+}
+
+func TestWatch(t *testing.T) {
+	dir := t.TempDir()
+	source := osfs.New(dir)
+	writeTestFile(t, source, "config.yml", "theme: theme\n")
+	writeTestFile(t, source, "theme/style.css", "body {}")
+	writeTestFile(t, source, "contents/a.md", "# a")
+
+	b, err := NewHollow(Option{SourceFs: source})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	closed := make(chan string, 2)
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		key := strings.TrimPrefix(r.URL.Path, "/_dev_/ws/")
+		b.serveWs(key, conn)
+		closed <- key
+	}))
+	defer s.Close()
+
+	dial := func(key string) *websocket.Conn {
+		conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(s.URL, "http")+"/_dev_/ws/"+key, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// 连接时 hub 会发送一条空消息
+		if _, _, err = conn.ReadMessage(); err != nil {
+			t.Fatal(err)
+		}
+		return conn
+	}
+	conn := dial(liveReloadKeyPrefix + "1")
+	defer conn.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go b.Watch(ctx)
+	// 等待开始监听
+	time.Sleep(200 * time.Millisecond)
+
+	read := func(conn *websocket.Conn) liveReloadEvent {
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		_, bs, err := conn.ReadMessage()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var e liveReloadEvent
+		if err = json.Unmarshal(bs, &e); err != nil {
+			t.Fatal(err)
+		}
+		return e
+	}
+
+	if err = os.WriteFile(filepath.Join(dir, "theme/style.css"), []byte("body {color: red}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, liveReloadEvent{Type: "css", Files: []string{filepath.Join(dir, "theme/style.css")}}, read(conn))
+
+	if err = os.WriteFile(filepath.Join(dir, "contents/a.md"), []byte("# b"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "reload", read(conn).Type)
+
+	// 页面关闭后连接的处理会结束，其他页面依然能收到消息
+	other := dial(liveReloadKeyPrefix + "2")
+	defer other.Close()
+	conn.Close()
+	select {
+	case key := <-closed:
+		assert.Equal(t, liveReloadKeyPrefix+"1", key)
+	case <-time.After(5 * time.Second):
+		t.Fatal("connection is not released after the client closed")
+	}
+	if err = os.WriteFile(filepath.Join(dir, "contents/a.md"), []byte("# c"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "reload", read(other).Type)
+}
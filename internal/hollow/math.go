@@ -0,0 +1,334 @@
+package hollow
+
+import (
+	"bytes"
+	"errors"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+	jsx "github.com/zbysir/gojsx"
+	"github.com/zbysir/hollow/internal/pkg/log"
+	"github.com/zbysir/hollow/internal/pkg/mathml"
+	stdhtml "html"
+	"os"
+	"strings"
+)
+
+// ConfigMath 配置数学公式的渲染，开启后 $...$ 与 $$...$$ 中的 LaTeX 会在构建时渲染为 MathML，不再需要在浏览器中渲染
+type ConfigMath struct {
+	Enable bool   `json:"enable" yaml:"enable"`
+	Output string `json:"output" yaml:"output"` // mathml（默认）或 katex，katex 会输出与 KaTeX 的 mathml 模式一致的结构，可以直接使用 KaTeX 的样式
+}
+
+func (c ConfigMath) output() string {
+	switch strings.ToLower(c.Output) {
+	case "", "mathml":
+		return "mathml"
+	case "katex":
+		return "katex"
+	}
+	log.Warnf("Unknown math output '%v', use mathml instead", c.Output)
+	return "mathml"
+}
+
+var (
+	kindMathInline = ast.NewNodeKind("MathInline")
+	kindMathBlock  = ast.NewNodeKind("MathBlock")
+)
+
+// mathInline 是段落中的 $...$，display 为 true 时为 $$...$$
+type mathInline struct {
+	ast.BaseInline
+	segment text.Segment
+	display bool
+}
+
+func (n *mathInline) Kind() ast.NodeKind {
+	return kindMathInline
+}
+
+func (n *mathInline) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, map[string]string{"Value": string(n.segment.Value(source))}, nil)
+}
+
+// mathBlock 是单独成行的 $$ 包裹的公式
+type mathBlock struct {
+	ast.BaseBlock
+	closed bool // 在同一行中结束，如 $$ x^2 $$
+}
+
+func (n *mathBlock) Kind() ast.NodeKind {
+	return kindMathBlock
+}
+
+// IsRaw 不解析公式中的内容
+func (n *mathBlock) IsRaw() bool {
+	return true
+}
+
+func (n *mathBlock) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, nil, nil)
+}
+
+// mathInlineParser 解析段落中的公式，规则与 pandoc 一致以避免误判金额等普通文本：
+// 开始的 $ 之后不能是空白，结束的 $ 之前不能是空白并且之后不能是数字，公式不能跨行。
+type mathInlineParser struct{}
+
+func (p *mathInlineParser) Trigger() []byte {
+	return []byte{'$'}
+}
+
+func (p *mathInlineParser) Parse(parent ast.Node, block text.Reader, pc parser.Context) ast.Node {
+	line, segment := block.PeekLine()
+	delim := 1
+	if len(line) > 1 && line[1] == '$' {
+		delim = 2
+	}
+	if len(line) <= delim || (delim == 1 && util.IsSpace(line[delim])) {
+		return nil
+	}
+
+	for i := delim; i < len(line); i++ {
+		switch line[i] {
+		case '\\':
+			i++
+			continue
+		case '$':
+		default:
+			continue
+		}
+		if delim == 2 {
+			if i+1 >= len(line) || line[i+1] != '$' {
+				continue
+			}
+		} else if util.IsSpace(line[i-1]) || (i+1 < len(line) && util.IsNumeric(line[i+1])) {
+			continue
+		}
+		if i == delim {
+			return nil
+		}
+		block.Advance(i + delim)
+		return &mathInline{
+			segment: text.NewSegment(segment.Start+delim, segment.Start+i),
+			display: delim == 2,
+		}
+	}
+	return nil
+}
+
+// mathBlockParser 解析以 $$ 开始的行，直到以 $$ 结束的行
+type mathBlockParser struct{}
+
+func (p *mathBlockParser) Trigger() []byte {
+	return []byte{'$'}
+}
+
+func (p *mathBlockParser) Open(parent ast.Node, reader text.Reader, pc parser.Context) (ast.Node, parser.State) {
+	line, segment := reader.PeekLine()
+	pos := pc.BlockOffset()
+	if pos < 0 || !bytes.HasPrefix(line[pos:], []byte("$$")) {
+		return nil, parser.NoChildren
+	}
+
+	rest := util.TrimRightSpace(line[pos+2:])
+	node := &mathBlock{}
+	if len(rest) == 0 {
+		return node, parser.NoChildren
+	}
+	// 只有 $$ x^2 $$ 独占一行时才是块级公式，否则交给 mathInlineParser 处理
+	if len(rest) < 3 || !bytes.HasSuffix(rest, []byte("$$")) || bytes.Contains(rest[:len(rest)-2], []byte("$$")) {
+		return nil, parser.NoChildren
+	}
+	start := segment.Start - segment.Padding + pos + 2
+	node.Lines().Append(text.NewSegment(start, start+len(rest)-2))
+	node.closed = true
+	reader.Advance(segment.Len() - 1)
+	return node, parser.NoChildren
+}
+
+func (p *mathBlockParser) Continue(node ast.Node, reader text.Reader, pc parser.Context) parser.State {
+	n := node.(*mathBlock)
+	if n.closed {
+		return parser.Close
+	}
+	line, segment := reader.PeekLine()
+	if trimmed := util.TrimRightSpace(line); bytes.HasSuffix(trimmed, []byte("$$")) {
+		if end := len(trimmed) - 2; !util.IsBlank(trimmed[:end]) {
+			n.Lines().Append(text.NewSegment(segment.Start, segment.Start+end))
+		}
+		reader.Advance(segment.Len() - 1)
+		return parser.Close
+	}
+
+	n.Lines().Append(segment)
+	reader.Advance(segment.Len() - 1)
+	return parser.Continue | parser.NoChildren
+}
+
+func (p *mathBlockParser) Close(node ast.Node, reader text.Reader, pc parser.Context) {}
+
+func (p *mathBlockParser) CanInterruptParagraph() bool {
+	return true
+}
+
+func (p *mathBlockParser) CanAcceptIndentedLine() bool {
+	return false
+}
+
+// mathHTMLRenderer 将公式渲染为与 pandoc 一致的 <span class="math inline">\(...\)</span>，
+// 在 mathRenderer 中再转换为 MathML，没有开启时也可以直接在浏览器中使用 KaTeX 等渲染。
+type mathHTMLRenderer struct{}
+
+func (r *mathHTMLRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(kindMathInline, r.renderMathInline)
+	reg.Register(kindMathBlock, r.renderMathBlock)
+}
+
+// escapeMath 转义公式，{} 同样需要转义，否则会在 mdx 中被当作表达式；换行也需要转义，否则会被 jsx 合并
+func escapeMath(tex string) string {
+	return strings.NewReplacer("{", "&#123;", "}", "&#125;", "\n", "&#10;").Replace(stdhtml.EscapeString(tex))
+}
+
+func (r *mathHTMLRenderer) renderMathInline(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+	n := node.(*mathInline)
+	tex := escapeMath(string(n.segment.Value(source)))
+	if n.display {
+		_, _ = w.WriteString(`<span class="math display">\[` + tex + `\]</span>`)
+	} else {
+		_, _ = w.WriteString(`<span class="math inline">\(` + tex + `\)</span>`)
+	}
+	return ast.WalkSkipChildren, nil
+}
+
+func (r *mathHTMLRenderer) renderMathBlock(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+	var sb strings.Builder
+	for i := 0; i < node.Lines().Len(); i++ {
+		line := node.Lines().At(i)
+		sb.Write(line.Value(source))
+	}
+	tex := escapeMath(strings.TrimSpace(sb.String()))
+	_, _ = w.WriteString(`<div class="math display">\[` + tex + `\]</div>` + "\n")
+	return ast.WalkSkipChildren, nil
+}
+
+// mathExtension 解析 $...$ 与 $$...$$
+type mathExtension struct{}
+
+func (e *mathExtension) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(
+		parser.WithBlockParsers(util.Prioritized(&mathBlockParser{}, 90)),
+		parser.WithInlineParsers(util.Prioritized(&mathInlineParser{}, 90)),
+	)
+	m.Renderer().AddOptions(renderer.WithNodeRenderers(util.Prioritized(&mathHTMLRenderer{}, 90)))
+}
+
+// mathRenderer 将内容中的公式渲染为 MathML
+type mathRenderer struct {
+	katex bool
+}
+
+// newMathRenderer 没有开启数学公式时返回 nil
+func newMathRenderer(conf ConfigMath) *mathRenderer {
+	if !conf.Enable {
+		return nil
+	}
+	return &mathRenderer{katex: conf.output() == "katex"}
+}
+
+// mathNode 返回 mathHTMLRenderer 生成的节点中的公式
+func mathNode(d jsx.VDom) (tex string, display bool, ok bool) {
+	attr, _ := d["attributes"].(map[string]interface{})
+	class, _ := attr["class"].(string)
+	if class == "" {
+		class, _ = attr["className"].(string)
+	}
+	switch class {
+	case "math inline":
+	case "math display":
+		display = true
+	default:
+		return "", false, false
+	}
+
+	switch t := attr["children"].(type) {
+	case string:
+		tex = t
+	case []interface{}:
+		if len(t) != 1 {
+			return "", false, false
+		}
+		tex, ok = t[0].(string)
+		if !ok {
+			return "", false, false
+		}
+	default:
+		return "", false, false
+	}
+
+	if display {
+		tex, ok = trimDelim(tex, `\[`, `\]`)
+	} else {
+		tex, ok = trimDelim(tex, `\(`, `\)`)
+	}
+	return tex, display, ok
+}
+
+func trimDelim(s string, left, right string) (string, bool) {
+	if !strings.HasPrefix(s, left) || !strings.HasSuffix(s, right) || len(s) < len(left)+len(right) {
+		return "", false
+	}
+	return s[len(left) : len(s)-len(right)], true
+}
+
+// rewrite 将公式替换为 <math> 元素，katex 模式下使用 <span class="katex"> 包裹
+func (r *mathRenderer) rewrite(dom jsx.VDom) {
+	if r == nil {
+		return
+	}
+	walkVDom(dom, func(d jsx.VDom) {
+		tex, display, ok := mathNode(d)
+		if !ok {
+			return
+		}
+		s, err := mathml.FromLatex(tex, display)
+		if err != nil {
+			log.Warnf("render math '%v' error: %v", tex, err)
+			return
+		}
+
+		attr := d["attributes"].(map[string]interface{})
+		class := attr["class"]
+		if class == nil {
+			class = attr["className"]
+		}
+		if r.katex {
+			class = "katex"
+			if display {
+				s = `<span class="katex">` + s + `</span>`
+				class = "katex-display"
+			}
+		}
+		d["attributes"] = map[string]interface{}{
+			"class":                   class,
+			"dangerouslySetInnerHTML": map[string]interface{}{"__html": s},
+		}
+	})
+}
+
+// mathRenderer 返回当前配置的公式渲染，没有开启时返回 nil
+func (b *Hollow) mathRenderer(ctx *RenderContext) *mathRenderer {
+	c, err := b.LoadConfig(ctx)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		log.Warnf("LoadConfig for math error: %v", err)
+	}
+	return newMathRenderer(c.Hollow.Math)
+}
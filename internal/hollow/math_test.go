@@ -0,0 +1,55 @@
+package hollow
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestMath(t *testing.T) {
+	b, f := newTestSite(t, map[string]string{
+		"config.yml":     "theme: theme\nmath:\n  enable: true\n",
+		"contents/a.md":  "it costs $5 and $10.\n\n$x^2$ is inline.\n\n$$\n\\frac{a}{b}\n$$\n",
+		"contents/b.mdx": "export const n = 1\n\n{n} and $\\sqrt{x}$\n\n$$ a < b $$\n",
+		"theme/index.jsx": `
+import {getContents, md} from "@bysir/hollow"
+
+export default {
+  pages: [{path: "", component: () => <div>
+    {getContents("contents").list.map(c => <section dangerouslySetInnerHTML={{__html: c.content}}/>)}
+    <footer dangerouslySetInnerHTML={{__html: md("$y$", {unwrap: true})}}/>
+  </div>}],
+  assets: [],
+}
+`,
+	})
+	build := func() string {
+		return readTestFile(t, buildTestSite(t, b, ExecOption{}), "index.html")
+	}
+
+	body := build()
+	// 金额不会被当作公式
+	assert.Contains(t, body, `<p>it costs $5 and $10.</p><p><span class="math inline"><math xmlns="http://www.w3.org/1998/Math/MathML"><semantics><msup><mi>x</mi><mn>2</mn></msup><annotation encoding="application/x-tex">x^2</annotation></semantics></math></span> is inline.</p>`)
+	assert.Contains(t, body, `<div class="math display"><math xmlns="http://www.w3.org/1998/Math/MathML" display="block"><semantics><mfrac><mi>a</mi><mi>b</mi></mfrac><annotation encoding="application/x-tex">\frac{a}{b}</annotation></semantics></math></div>`)
+	// mdx 中的表达式依然生效，公式中的 {} 不会被当作表达式
+	assert.Contains(t, body, `<p>1 and <span class="math inline"><math xmlns="http://www.w3.org/1998/Math/MathML"><semantics><msqrt><mi>x</mi></msqrt><annotation encoding="application/x-tex">\sqrt{x}</annotation></semantics></math></span></p>`)
+	assert.Contains(t, body, `<div class="math display"><math xmlns="http://www.w3.org/1998/Math/MathML" display="block"><semantics><mrow><mi>a</mi><mo>&lt;</mo><mi>b</mi></mrow><annotation encoding="application/x-tex">a &lt; b</annotation></semantics></math></div>`)
+	assert.Contains(t, body, `<footer><span class="math inline"><math xmlns="http://www.w3.org/1998/Math/MathML"><semantics><mi>y</mi><annotation encoding="application/x-tex">y</annotation></semantics></math></span></footer>`)
+
+	// katex 模式
+	writeTestFile(t, f, "config.yml", "theme: theme\nmath:\n  enable: true\n  output: katex\n")
+	body = build()
+	assert.Contains(t, body, `<span class="katex"><math xmlns="http://www.w3.org/1998/Math/MathML"><semantics><msup>`)
+	assert.Contains(t, body, `<div class="katex-display"><span class="katex"><math xmlns="http://www.w3.org/1998/Math/MathML" display="block"><semantics><mfrac>`)
+
+	// 没有开启时不解析 $，保持原样
+	writeTestFile(t, f, "config.yml", "theme: theme\n")
+	writeTestFile(t, f, "contents/c.md", "copy to $HOME/$USER now\n")
+	if err := f.Remove("contents/b.mdx"); err != nil {
+		t.Fatal(err)
+	}
+	body = build()
+	assert.Contains(t, body, `<p>copy to $HOME/$USER now</p>`)
+	assert.Contains(t, body, `<p>$x^2$ is inline.</p>`)
+	assert.Contains(t, body, `<footer>$y$</footer>`)
+	assert.NotContains(t, body, `class="math`)
+}
@@ -0,0 +1,375 @@
+package hollow
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/dop251/goja"
+	"github.com/zbysir/hollow/internal/pkg/log"
+	"github.com/zbysir/hollow/internal/pkg/util"
+	"hash/fnv"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// ConfigSearch 声明构建时生成的搜索索引，会在 Path 目录下生成 index.json 与 shard-N.json
+type ConfigSearch struct {
+	Enable bool   `json:"enable" yaml:"enable"`
+	Source string `json:"source" yaml:"source"` // 文章目录，与 getContents 的参数一致，默认为 contents
+	Link   string `json:"link" yaml:"link"`     // 文章的页面路径，支持 :slug 与 :name 参数，默认为 :slug
+	Path   string `json:"path" yaml:"path"`     // 输出目录，默认为 search
+	Shards int    `json:"shards" yaml:"shards"` // 分片数量，默认根据词的数量计算
+}
+
+func (c ConfigSearch) source() string {
+	if c.Source == "" {
+		return "contents"
+	}
+	return c.Source
+}
+
+func (c ConfigSearch) dir() string {
+	if c.Path == "" {
+		return "search"
+	}
+	return strings.Trim(c.Path, "/")
+}
+
+const (
+	searchIndexFile = "index.json"
+	// searchShardTerms 是自动计算分片数量时每个分片的词数
+	searchShardTerms = 5000
+
+	// 不同位置的词的权重
+	searchTitleWeight   = 10
+	searchHeadingWeight = 5
+	searchBodyWeight    = 1
+)
+
+// searchDoc 是索引中的一篇文章，字段名尽量短以减小索引的体积
+type searchDoc struct {
+	Title    string      `json:"t"`
+	Url      string      `json:"u"`
+	Headings [][2]string `json:"h,omitempty"` // [[标题, id]]
+}
+
+// searchIndex 是 index.json 的内容，主题先加载它，再根据词的 hash 加载对应的分片：
+//
+//	shard = fnv1a32(utf8(term)) % shards
+//	fetch(`${dir}/shard-${shard}.json`)[term] // [[文档序号, 分数], ...]
+type searchIndex struct {
+	Shards int         `json:"shards"`
+	Docs   []searchDoc `json:"docs"`
+}
+
+type searchFile struct {
+	Name string
+	Body []byte
+}
+
+// tokenize 分词：中日韩文字使用二元分词（单个字时为一元），其他文字按单词分割并转为小写
+func tokenize(text string) []string {
+	var tokens []string
+	var word []rune
+	var cjk []rune
+
+	flushWord := func() {
+		if len(word) != 0 {
+			tokens = append(tokens, strings.ToLower(string(word)))
+			word = word[:0]
+		}
+	}
+	flushCjk := func() {
+		switch len(cjk) {
+		case 0:
+		case 1:
+			tokens = append(tokens, string(cjk))
+		default:
+			for i := 0; i < len(cjk)-1; i++ {
+				tokens = append(tokens, string(cjk[i:i+2]))
+			}
+		}
+		cjk = cjk[:0]
+	}
+
+	for _, r := range text {
+		switch {
+		case isCJK(r):
+			flushWord()
+			cjk = append(cjk, r)
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			flushCjk()
+			word = append(word, r)
+		default:
+			flushWord()
+			flushCjk()
+		}
+	}
+	flushWord()
+	flushCjk()
+	return tokens
+}
+
+func searchShard(term string, shards int) int {
+	h := fnv.New32a()
+	h.Write([]byte(term))
+	return int(h.Sum32() % uint32(shards))
+}
+
+// tocHeadings 返回目录中的所有标题
+func tocHeadings(items []*TocItem) [][2]string {
+	var hs [][2]string
+	for _, i := range items {
+		hs = append(hs, [2]string{plainText(i.Title), i.Id})
+		hs = append(hs, tocHeadings(i.Items)...)
+	}
+	return hs
+}
+
+// searchPage 是一个动态页面，用于找到文章对应的页面地址
+type searchPage struct {
+	url     string
+	params  []string          // 参数中的字符串，如 getPaths 返回的 {slug: 'a'} 中的 a
+	sources map[string]string // 渲染页面时读取的文件，开发服务中没有渲染页面时为 nil
+}
+
+// newSearchPages 返回所有动态页面，manifest 为 nil 时不记录页面读取的文件
+func newSearchPages(pages Pages, distFiles []string, manifest *buildManifest) []searchPage {
+	var sps []searchPage
+	for i, p := range pages {
+		v, ok := p["params"].(goja.Value)
+		if !ok {
+			continue
+		}
+		params, _ := v.Export().(map[string]interface{})
+		sp := searchPage{url: pageUrl("", distFiles[i])}
+		for _, v := range params {
+			if s, ok := v.(string); ok {
+				sp.params = append(sp.params, s)
+			}
+		}
+		sort.Strings(sp.params)
+		if manifest != nil {
+			mp, ok := manifest.Pages[distFiles[i]]
+			if !ok {
+				// 渲染失败的页面
+				continue
+			}
+			sp.sources = mp.Sources
+		}
+		sps = append(sps, sp)
+	}
+	return sps
+}
+
+// docUrl 返回文章的页面地址：参数中包含文章的 slug 或者文件名，并且读取了该文章的唯一动态页面，
+// 找不到或者有多个页面时使用 search.link 生成。
+func docUrl(c Content, link string, pages []searchPage) (string, error) {
+	slug := firstNonEmpty(metaString(c.Meta, "slug"), c.Name)
+	var urls []string
+	for _, p := range pages {
+		if p.sources != nil {
+			if _, ok := p.sources[cleanSourcePath(c.File)]; !ok {
+				continue
+			}
+		}
+		for _, v := range p.params {
+			if v == slug || v == c.Name {
+				urls = append(urls, p.url)
+				break
+			}
+		}
+	}
+	if len(urls) == 1 {
+		return urls[0], nil
+	}
+
+	p, err := fillPathPattern(link, map[string]interface{}{
+		"name": c.Name,
+		"slug": slug,
+	})
+	if err != nil {
+		return "", err
+	}
+	// 与页面的输出路径保持一致，posts/a => posts/a/
+	if path.Ext(p) == "" {
+		p += "/"
+	}
+	return "/" + strings.TrimPrefix(p, "/"), nil
+}
+
+// buildSearch 生成搜索索引文件，pages 用于找到文章对应的页面地址
+func (b *Hollow) buildSearch(ctx *RenderContext, conf ConfigSearch, pages []searchPage) ([]searchFile, error) {
+	if !conf.Enable {
+		return nil, nil
+	}
+	link := conf.Link
+	if link == "" {
+		link = ":slug"
+	}
+
+	list := b.getContents(ctx)(conf.source(), getBlogOption{})
+
+	index := searchIndex{Docs: make([]searchDoc, 0, len(list.List))}
+	// term => 文档序号 => 分数
+	postings := map[string]map[int]int{}
+	add := func(doc int, text string, weight int) {
+		for _, t := range tokenize(text) {
+			p, ok := postings[t]
+			if !ok {
+				p = map[int]int{}
+				postings[t] = p
+			}
+			p[doc] += weight
+		}
+	}
+
+	for _, c := range list.List {
+		u, err := docUrl(c.Content, link, pages)
+		if err != nil {
+			return nil, fmt.Errorf("search link of '%v' error: %w", c.Name, err)
+		}
+
+		doc := len(index.Docs)
+		d := searchDoc{
+			Title:    firstNonEmpty(metaString(c.Meta, "title"), c.Name),
+			Url:      u,
+			Headings: tocHeadings(c.Toc),
+		}
+		index.Docs = append(index.Docs, d)
+
+		add(doc, d.Title, searchTitleWeight)
+		for _, h := range d.Headings {
+			add(doc, h[0], searchHeadingWeight)
+		}
+		add(doc, plainText(c.Content.Content), searchBodyWeight)
+	}
+
+	shards := conf.Shards
+	if shards <= 0 {
+		shards = (len(postings) + searchShardTerms - 1) / searchShardTerms
+	}
+	if shards < 1 {
+		shards = 1
+	}
+	index.Shards = shards
+
+	shardTerms := make([]map[string][][2]int, shards)
+	for i := range shardTerms {
+		shardTerms[i] = map[string][][2]int{}
+	}
+	for term, p := range postings {
+		ps := make([][2]int, 0, len(p))
+		for doc, score := range p {
+			ps = append(ps, [2]int{doc, score})
+		}
+		// 分数高的在前
+		sort.Slice(ps, func(i, j int) bool {
+			if ps[i][1] != ps[j][1] {
+				return ps[i][1] > ps[j][1]
+			}
+			return ps[i][0] < ps[j][0]
+		})
+		shardTerms[searchShard(term, shards)][term] = ps
+	}
+
+	dir := conf.dir()
+	body, err := json.Marshal(index)
+	if err != nil {
+		return nil, err
+	}
+	files := []searchFile{{Name: path.Join(dir, searchIndexFile), Body: body}}
+	for i, s := range shardTerms {
+		body, err := json.Marshal(s)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, searchFile{Name: path.Join(dir, fmt.Sprintf("shard-%d.json", i)), Body: body})
+	}
+	return files, nil
+}
+
+// searchCache 缓存开发服务中生成的搜索索引，index.json 与分片是分开请求的，文章没有变化时不需要重新生成
+type searchCache struct {
+	key   string
+	files []searchFile
+}
+
+// searchCacheKey 返回搜索索引的缓存 key，由配置、页面地址与文章目录中所有文件的内容决定
+func (b *Hollow) searchCacheKey(ctx *RenderContext, conf ConfigSearch, pages []searchPage) string {
+	var sb strings.Builder
+	bs, _ := json.Marshal(conf)
+	sb.Write(bs)
+	fmt.Fprintf(&sb, "\n%v", ctx.showDrafts)
+	for _, p := range pages {
+		fmt.Fprintf(&sb, "\n%v %v", p.url, p.params)
+	}
+
+	hasher := newSourceHasher(b.sourceStdFs)
+	files := append([]string{}, configFiles...)
+	_ = fs.WalkDir(b.sourceStdFs, conf.source(), func(p string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			files = append(files, p)
+		}
+		return nil
+	})
+	for _, f := range files {
+		fmt.Fprintf(&sb, "\n%v %v", f, hasher.hash(f))
+	}
+	return util.MD5(sb.String())
+}
+
+// matchSearch 在开发服务中返回请求的搜索索引文件，文章没有变化时使用上一次生成的索引
+func (b *Hollow) matchSearch(ctx *RenderContext, conf ConfigSearch, theme ThemeExport, reqPath string) (searchFile, bool, error) {
+	if !conf.Enable || path.Dir(reqPath) != conf.dir() || path.Ext(reqPath) != ".json" {
+		return searchFile{}, false, nil
+	}
+	pages, err := theme.ExpandPages()
+	if err != nil {
+		return searchFile{}, false, err
+	}
+	distFiles := make([]string, len(pages))
+	for i, p := range pages {
+		distFiles[i] = p.distFile()
+	}
+	searchPages := newSearchPages(pages, distFiles, nil)
+
+	key := b.searchCacheKey(ctx, conf, searchPages)
+	b.searchLock.Lock()
+	cache := b.searchCache
+	b.searchLock.Unlock()
+	if cache.key != key {
+		files, err := b.buildSearch(ctx, conf, searchPages)
+		if err != nil {
+			return searchFile{}, false, err
+		}
+		cache = searchCache{key: key, files: files}
+		b.searchLock.Lock()
+		b.searchCache = cache
+		b.searchLock.Unlock()
+	}
+	for _, f := range cache.files {
+		if f.Name == reqPath {
+			return f, true, nil
+		}
+	}
+	return searchFile{}, false, nil
+}
+
+// getSearchIndexUrl 返回搜索索引 index.json 的地址，如 /search/index.json，没有开启搜索时返回空字符串
+func (b *Hollow) getSearchIndexUrl(ctx *RenderContext) func() string {
+	return func() string {
+		c, err := b.LoadConfig(ctx)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			log.Warnf("LoadConfig for getSearchIndexUrl error: %v", err)
+		}
+		if !c.Hollow.Search.Enable {
+			return ""
+		}
+		return "/" + path.Join(c.Hollow.Search.dir(), searchIndexFile)
+	}
+}
@@ -0,0 +1,108 @@
+package hollow
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	assert.Equal(t, []string{"hello", "go", "语言", "言开", "开发", "中"}, tokenize("Hello, Go 语言开发 中"))
+	assert.Equal(t, []string(nil), tokenize(" ,.!"))
+}
+
+func TestBuildSearch(t *testing.T) {
+	b, f := newTestSite(t, map[string]string{
+		"config.yml":    "theme: theme\nsearch:\n  enable: true\n  shards: 2\n",
+		"contents/a.md": "---\ntitle: 使用 Hollow\nslug: use-hollow\n---\n## 安装\n\nhollow 是一个博客生成器",
+		"contents/b.md": "---\ntitle: Go\n---\n安装 go",
+		"theme/index.jsx": `
+import {getSearchIndexUrl} from "@bysir/hollow"
+
+export default {
+  pages: [{path: "", component: () => <div>{getSearchIndexUrl()}</div>}],
+  assets: [],
+}
+`,
+	})
+	dst := buildTestSite(t, b, ExecOption{})
+	assert.Equal(t, "<div>/search/index.json</div>", readTestFile(t, dst, "index.html"))
+
+	var index searchIndex
+	if err := json.Unmarshal([]byte(readTestFile(t, dst, "search/index.json")), &index); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 2, index.Shards)
+	assert.Equal(t, []searchDoc{
+		{Title: "使用 Hollow", Url: "/use-hollow/", Headings: [][2]string{{"安装", "heading"}}},
+		{Title: "Go", Url: "/b/"},
+	}, index.Docs)
+
+	lookup := func(term string) [][2]int {
+		bs := readTestFile(t, dst, fmt.Sprintf("search/shard-%d.json", searchShard(term, index.Shards)))
+		var shard map[string][][2]int
+		if err := json.Unmarshal([]byte(bs), &shard); err != nil {
+			t.Fatal(err)
+		}
+		return shard[term]
+	}
+	// 标题中的词权重更高
+	assert.Equal(t, [][2]int{{0, searchTitleWeight + searchBodyWeight}}, lookup("hollow"))
+	assert.Equal(t, [][2]int{{1, searchTitleWeight + searchBodyWeight}}, lookup("go"))
+	assert.Equal(t, [][2]int{{0, searchHeadingWeight + searchBodyWeight}, {1, searchBodyWeight}}, lookup("安装"))
+	assert.Equal(t, [][2]int{{0, searchBodyWeight}}, lookup("博客"))
+
+	// 文章的地址使用 getPaths 生成的页面
+	writeTestFile(t, f, "theme/index.jsx", `
+import {getContents, getContentDetail} from "@bysir/hollow"
+
+const slug = c => c.meta.slug || c.name
+
+export default {
+  pages: [
+    {
+      path: "posts/:slug",
+      getPaths: () => getContents("contents").list.map(c => ({slug: slug(c), file: "contents/" + c.name + ".md"})),
+      component: ({file}) => <div>{getContentDetail(file).meta.title}</div>,
+    },
+    {
+      path: "tags/:tag",
+      getPaths: () => [{tag: "go"}],
+      component: () => <div>{getContents("contents").list.length}</div>,
+    },
+  ],
+  assets: [],
+}
+`)
+	docs := func(body []byte) []searchDoc {
+		var index searchIndex
+		if err := json.Unmarshal(body, &index); err != nil {
+			t.Fatal(err)
+		}
+		return index.Docs
+	}
+	dst = buildTestSite(t, b, ExecOption{})
+	assert.Equal(t, []searchDoc{
+		{Title: "使用 Hollow", Url: "/posts/use-hollow/", Headings: [][2]string{{"安装", "heading"}}},
+		{Title: "Go", Url: "/posts/b/"},
+	}, docs([]byte(readTestFile(t, dst, "search/index.json"))))
+
+	// 开发服务中文章没有变化时使用缓存的索引
+	handle := b.ServiceHandle(ExecOption{IsDev: true})
+	get := func(p string) []byte {
+		w := httptest.NewRecorder()
+		handle(w, httptest.NewRequest("GET", p, nil))
+		assert.Equal(t, 200, w.Code, p)
+		return w.Body.Bytes()
+	}
+	assert.Equal(t, "/posts/b/", docs(get("/search/index.json"))[1].Url)
+	key := b.searchCache.key
+	get(fmt.Sprintf("/search/shard-%d.json", searchShard("go", index.Shards)))
+	assert.Equal(t, key, b.searchCache.key)
+
+	writeTestFile(t, f, "contents/b.md", "---\ntitle: Golang\n---\n安装 go")
+	assert.Equal(t, "Golang", docs(get("/search/index.json"))[1].Title)
+	assert.NotEqual(t, key, b.searchCache.key)
+}
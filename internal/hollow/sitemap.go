@@ -0,0 +1,205 @@
+package hollow
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"github.com/dop251/goja"
+	"github.com/go-git/go-billy/v5"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// sitemapEntry 是 sitemap.xml 中的一个页面
+type sitemapEntry struct {
+	Loc        string `xml:"loc"`
+	Lastmod    string `xml:"lastmod,omitempty"`
+	Changefreq string `xml:"changefreq,omitempty"`
+	Priority   string `xml:"priority,omitempty"`
+}
+
+type sitemapUrlSet struct {
+	XMLName xml.Name       `xml:"urlset"`
+	Xmlns   string         `xml:"xmlns,attr"`
+	Urls    []sitemapEntry `xml:"url"`
+}
+
+// metaDateKeys 是读取内容更新时间的 meta 字段，按优先级排列
+var metaDateKeys = []string{"lastmod", "updated", "date"}
+
+var dateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+	"Mon Jan 02 2006 15:04:05 GMT-0700 (MST)",
+}
+
+func parseDate(i interface{}) (time.Time, bool) {
+	switch t := i.(type) {
+	case time.Time:
+		return t, true
+	case string:
+		for _, l := range dateLayouts {
+			d, err := time.Parse(l, t)
+			if err == nil {
+				return d, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// pageField 返回页面中的字段，如 priority
+func pageField(p Page, key string) (interface{}, bool) {
+	v, ok := p[key]
+	if !ok || v == nil {
+		return nil, false
+	}
+	if g, ok := v.(goja.Value); ok {
+		if goja.IsUndefined(g) || goja.IsNull(g) {
+			return nil, false
+		}
+		return g.Export(), true
+	}
+	return v, true
+}
+
+// sourceLastmod 返回 source 文件的更新时间，优先读取 meta 中的日期，其次是文件修改时间
+func (b *Hollow) sourceLastmod(file string) (time.Time, bool) {
+	meta := b.tryReadMeta(file)
+	for _, k := range metaDateKeys {
+		if d, ok := parseDate(meta[k]); ok {
+			return d, true
+		}
+	}
+
+	stat, err := fs.Stat(b.sourceStdFs, file)
+	if err != nil || stat.IsDir() {
+		return time.Time{}, false
+	}
+	return stat.ModTime(), true
+}
+
+// sourceLastmods 在一次构建中缓存 source 文件的更新时间，多个页面读取了同一个文件时只会计算一次
+type sourceLastmods struct {
+	b     *Hollow
+	cache map[string]time.Time
+}
+
+func (s *sourceLastmods) get(file string) (time.Time, bool) {
+	if d, ok := s.cache[file]; ok {
+		return d, !d.IsZero()
+	}
+	d, _ := s.b.sourceLastmod(file)
+	s.cache[file] = d
+	return d, !d.IsZero()
+}
+
+// pageLastmod 返回页面的更新时间：页面的 lastmod 字段，或者渲染页面时读取的所有内容中最新的时间
+func (s *sourceLastmods) pageLastmod(p Page, sources map[string]string) (time.Time, bool) {
+	if v, ok := pageField(p, "lastmod"); ok {
+		return parseDate(v)
+	}
+
+	var last time.Time
+	for file := range sources {
+		d, ok := s.get(file)
+		if ok && d.After(last) {
+			last = d
+		}
+	}
+	return last, !last.IsZero()
+}
+
+// pageUrl 返回页面的完整地址，distFile 是输出文件路径，如 posts/a/index.html => https://example.com/posts/a/
+func pageUrl(baseUrl string, distFile string) string {
+	baseUrl = strings.TrimSuffix(baseUrl, "/")
+	p := strings.TrimSuffix(filepath.ToSlash(distFile), "index.html")
+	return baseUrl + "/" + p
+}
+
+// buildSitemap 使用所有 html 页面生成 sitemap.xml，页面可以使用以下字段覆盖默认值：
+//   - sitemap: false 不出现在 sitemap 中
+//   - lastmod: 更新时间，默认为页面读取的内容中的 meta.date 或者文件修改时间
+//   - priority / changefreq
+func (b *Hollow) buildSitemap(baseUrl string, pages Pages, distFiles []string, manifest *buildManifest) ([]byte, error) {
+	var urls []sitemapEntry
+	lastmods := &sourceLastmods{b: b, cache: map[string]time.Time{}}
+	for i, p := range pages {
+		distFile := distFiles[i]
+		if path.Ext(distFile) != ".html" {
+			continue
+		}
+		if v, ok := pageField(p, "sitemap"); ok {
+			if enable, ok := v.(bool); ok && !enable {
+				continue
+			}
+		}
+
+		e := sitemapEntry{
+			Loc: pageUrl(baseUrl, distFile),
+		}
+		if d, ok := lastmods.pageLastmod(p, manifest.Pages[distFile].Sources); ok {
+			e.Lastmod = d.Format("2006-01-02")
+		}
+		if v, ok := pageField(p, "changefreq"); ok {
+			e.Changefreq = fmt.Sprintf("%v", v)
+		}
+		if v, ok := pageField(p, "priority"); ok {
+			e.Priority = fmt.Sprintf("%v", v)
+		}
+		urls = append(urls, e)
+	}
+
+	sort.SliceStable(urls, func(i, j int) bool {
+		return urls[i].Loc < urls[j].Loc
+	})
+
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	enc := xml.NewEncoder(&buf)
+	enc.Indent("", "  ")
+	err := enc.Encode(sitemapUrlSet{
+		Xmlns: "http://www.sitemaps.org/schemas/sitemap/0.9",
+		Urls:  urls,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func buildRobots(baseUrl string) []byte {
+	return []byte(fmt.Sprintf("User-agent: *\nAllow: /\n\nSitemap: %v/sitemap.xml\n", strings.TrimSuffix(baseUrl, "/")))
+}
+
+// hasOwnFile 返回页面或者静态文件中是否已经有 name（如 robots.txt），此时不应该生成覆盖它
+func hasOwnFile(name string, distFiles []string, themeAssets []string, themeFs fs.FS, assets []string, sourceFs fs.FS) bool {
+	if hasFile(distFiles, name) {
+		return true
+	}
+	exist := func(dirs []string, f fs.FS) bool {
+		for _, d := range dirs {
+			if _, err := fs.Stat(f, path.Join(d, name)); err == nil {
+				return true
+			}
+		}
+		return false
+	}
+	return exist(themeAssets, themeFs) || exist(assets, sourceFs)
+}
+
+func writeFile(dst billy.Filesystem, name string, body []byte) error {
+	f, err := dst.Create(name)
+	if err != nil {
+		return fmt.Errorf("create file '%v' error: %w", name, err)
+	}
+	defer f.Close()
+
+	_, err = f.Write(body)
+	return err
+}
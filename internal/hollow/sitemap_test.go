@@ -0,0 +1,94 @@
+package hollow
+
+import (
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/go-git/go-billy/v5/util"
+	"github.com/stretchr/testify/assert"
+	"path/filepath"
+	"testing"
+)
+
+func TestSitemap(t *testing.T) {
+	source := memfs.New()
+	writeTestFile(t, source, "config.yml", "theme: theme\nbase_url: https://example.com/\nsitemap:\n  enable: true\n")
+	writeTestFile(t, source, "contents/a.md", "---\ndate: 2022-03-04\n---\n# a")
+	writeTestFile(t, source, "theme/index.jsx", `
+import {getContentDetail} from "@bysir/hollow"
+
+export default {
+  pages: [
+    {path: "", component: () => <div>home</div>, priority: 1, changefreq: "daily", lastmod: "2023-01-02"},
+    {path: "posts/a", component: () => <div>{getContentDetail("contents/a.md").name}</div>},
+    {path: "secret", component: () => <div>secret</div>, sitemap: false},
+    {path: "feed.xml", component: () => <rss></rss>},
+  ],
+  assets: [],
+}
+`)
+
+	b, err := NewHollow(Option{SourceFs: source})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dst := memfs.New()
+	err = b.BuildToFs(NewRenderContext(), dst, ExecOption{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sitemap, err := util.ReadFile(dst, "sitemap.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url>
+    <loc>https://example.com/</loc>
+    <lastmod>2023-01-02</lastmod>
+    <changefreq>daily</changefreq>
+    <priority>1</priority>
+  </url>
+  <url>
+    <loc>https://example.com/posts/a/</loc>
+    <lastmod>2022-03-04</lastmod>
+  </url>
+</urlset>`, string(sitemap))
+
+	robots, err := util.ReadFile(dst, "robots.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "User-agent: *\nAllow: /\n\nSitemap: https://example.com/sitemap.xml\n", string(robots))
+
+	// 项目中已有 robots.txt 时不覆盖
+	writeTestFile(t, source, "config.yml", "theme: theme\nbase_url: https://example.com\nsitemap:\n  enable: true\nassets: [statics]\n")
+	writeTestFile(t, source, "statics/robots.txt", "User-agent: *\nDisallow: /\n")
+	err = b.BuildToFs(NewRenderContext(), dst, ExecOption{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	robots, err = util.ReadFile(dst, "robots.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "User-agent: *\nDisallow: /\n", string(robots))
+
+	// 项目中已有 sitemap.xml 时不覆盖
+	writeTestFile(t, source, "statics/sitemap.xml", "<urlset/>")
+	dst = memfs.New()
+	err = b.BuildToFs(NewRenderContext(), dst, ExecOption{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sitemap, err = util.ReadFile(dst, "sitemap.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "<urlset/>", string(sitemap))
+}
+
+func TestPageUrl(t *testing.T) {
+	assert.Equal(t, "https://example.com/posts/a/", pageUrl("https://example.com/", filepath.Join("posts", "a", "index.html")))
+	assert.Equal(t, "https://example.com/feed.xml", pageUrl("https://example.com", "feed.xml"))
+}
@@ -0,0 +1,166 @@
+package hollow
+
+import (
+	"errors"
+	"fmt"
+	"github.com/zbysir/hollow/internal/pkg/log"
+	"io/fs"
+	"sort"
+	"strings"
+)
+
+type ConfigTaxonomy struct {
+	Source string   `json:"source" yaml:"source"` // 内容文件夹，默认为 contents
+	Names  []string `json:"names" yaml:"names"`   // 分类名称，对应文章 meta 中的字段，默认为 tags 与 categories
+}
+
+func (c ConfigTaxonomy) source() string {
+	if c.Source != "" {
+		return c.Source
+	}
+	return "contents"
+}
+
+func (c ConfigTaxonomy) names() []string {
+	if len(c.Names) != 0 {
+		return c.Names
+	}
+	return []string{"tags", "categories"}
+}
+
+type TaxonomyTerm struct {
+	Name  string        `json:"name"`
+	Count int           `json:"count"`
+	List  []ContentTree `json:"list"` // 按照 meta.date 倒序排序
+}
+
+type taxonomyOption struct {
+	Lang string `json:"lang"` // 只统计该语言的内容
+}
+
+// taxonomyIndex 分类名称 => 按照数量倒序排序的 term
+type taxonomyIndex struct {
+	taxonomies map[string][]TaxonomyTerm
+	deps       []string // 生成索引时读取的 source 文件，缓存命中时也需要记录依赖
+}
+
+// metaTerms 返回 meta 中的 term，支持字符串与数组，如 tags: [go, js] 或 category: go
+func metaTerms(v interface{}) []string {
+	var vs []interface{}
+	switch t := v.(type) {
+	case nil:
+		return nil
+	case []interface{}:
+		vs = t
+	default:
+		vs = []interface{}{t}
+	}
+
+	var terms []string
+	exist := map[string]bool{}
+	for _, v := range vs {
+		if v == nil {
+			continue
+		}
+		s := strings.TrimSpace(fmt.Sprint(v))
+		if s == "" || exist[s] {
+			continue
+		}
+		exist[s] = true
+		terms = append(terms, s)
+	}
+	return terms
+}
+
+// taxonomyIndex 读取所有内容并按照分类分组，同一个 RenderContext 中只会生成一次
+func (b *Hollow) taxonomyIndex(ctx *RenderContext, lang string) taxonomyIndex {
+	cacheKey := "taxonomyIndex:" + lang
+	if x, ok := ctx.cache.Get(cacheKey); ok {
+		index := x.(taxonomyIndex)
+		ctx.dependOn(index.deps...)
+		return index
+	}
+
+	c, err := b.LoadConfig(ctx)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		log.Warnf("LoadConfig for taxonomy error: %v", err)
+	}
+	conf := c.Hollow.Taxonomy
+
+	// 使用 fork 收集 getContents 的依赖
+	f := ctx.fork()
+	contents := b.getContents(f)(conf.source(), getBlogOption{
+		Sort: func(x, y interface{}) bool {
+			return lessMeta(y.(ContentTree).Meta["date"], x.(ContentTree).Meta["date"])
+		},
+		Lang: lang,
+	}).List
+	index := taxonomyIndex{
+		taxonomies: map[string][]TaxonomyTerm{},
+		deps:       f.takeDeps(),
+	}
+	ctx.merge(f)
+	ctx.dependOn(index.deps...)
+
+	for _, name := range conf.names() {
+		terms := map[string]*TaxonomyTerm{}
+		var list []*TaxonomyTerm
+		for _, content := range contents {
+			for _, term := range metaTerms(content.Meta[name]) {
+				t, ok := terms[term]
+				if !ok {
+					t = &TaxonomyTerm{Name: term}
+					terms[term] = t
+					list = append(list, t)
+				}
+				t.Count++
+				t.List = append(t.List, content)
+			}
+		}
+
+		sort.SliceStable(list, func(i, j int) bool {
+			if list[i].Count != list[j].Count {
+				return list[i].Count > list[j].Count
+			}
+			return list[i].Name < list[j].Name
+		})
+		ts := make([]TaxonomyTerm, len(list))
+		for i, t := range list {
+			ts[i] = *t
+		}
+		index.taxonomies[name] = ts
+	}
+
+	ctx.cache.Add(cacheKey, index)
+	return index
+}
+
+// getTaxonomy 返回分类下的所有 term 与其内容，按照内容数量倒序排序，如：
+// getTaxonomy('tags').map(t => ({path: 'tags/' + t.name, component: () => <List list={t.list}/>}))
+func (b *Hollow) getTaxonomy(ctx *RenderContext) func(name string, opt taxonomyOption) []TaxonomyTerm {
+	return func(name string, opt taxonomyOption) []TaxonomyTerm {
+		end := ctx.timerStart("getTaxonomy")
+		defer end()
+
+		ts := b.taxonomyIndex(ctx, opt.Lang).taxonomies[name]
+		if ts == nil {
+			return []TaxonomyTerm{}
+		}
+		return ts
+	}
+}
+
+// getTerms 返回分类中 term 下的所有内容，按照 meta.date 倒序排序，如 getTerms('tags', 'golang')
+func (b *Hollow) getTerms(ctx *RenderContext) func(name string, term string, opt taxonomyOption) []ContentTree {
+	return func(name string, term string, opt taxonomyOption) []ContentTree {
+		end := ctx.timerStart("getTerms")
+		defer end()
+
+		for _, t := range b.taxonomyIndex(ctx, opt.Lang).taxonomies[name] {
+			if t.Name == term {
+				return t.List
+			}
+		}
+		return []ContentTree{}
+	}
+}
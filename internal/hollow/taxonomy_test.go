@@ -0,0 +1,59 @@
+package hollow
+
+import (
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/go-git/go-billy/v5/util"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestTaxonomy(t *testing.T) {
+	source := memfs.New()
+	writeTestFile(t, source, "config.yml", "theme: theme\ntaxonomy:\n  names: [tags, series]\n")
+	writeTestFile(t, source, "contents/a.md", "---\ndate: 2022-01-01\ntags: [go, js]\nseries: hollow\n---\na")
+	writeTestFile(t, source, "contents/b.md", "---\ndate: 2022-03-01\ntags: [go]\n---\nb")
+	writeTestFile(t, source, "contents/c.md", "---\ndate: 2022-02-01\ntags: [css, go, go]\n---\nc")
+	writeTestFile(t, source, "theme/index.jsx", `
+import {getTaxonomy, getTerms} from "@bysir/hollow"
+
+export default {
+  pages: [
+    ...getTaxonomy("tags").map(t => ({
+      path: "tags/" + t.name,
+      component: () => <ul>{getTerms("tags", t.name).map(c => <li>{c.name}</li>)}</ul>,
+    })),
+    {path: "", component: () => <p>{getTaxonomy("tags").map(t => t.name + ":" + t.count).join(",")}|{getTaxonomy("series").map(t => t.name).join(",")}|{getTaxonomy("categories").length}</p>},
+  ],
+  assets: [],
+}
+`)
+
+	b, err := NewHollow(Option{SourceFs: source})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dst := memfs.New()
+	err = b.BuildToFs(NewRenderContext(), dst, ExecOption{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, body := range map[string]string{
+		"index.html":         "<p>go:3,css:1,js:1|hollow|0</p>",
+		"tags/go/index.html": "<ul><li>b</li><li>c</li><li>a</li></ul>",
+		"tags/js/index.html": "<ul><li>a</li></ul>",
+	} {
+		bs, err := util.ReadFile(dst, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, body, string(bs), name)
+	}
+
+	// 缓存命中时也会记录依赖，用于增量构建
+	ctx := NewRenderContext()
+	b.getTaxonomy(ctx)("tags", taxonomyOption{})
+	assert.Contains(t, ctx.takeDeps(), "contents/a.md")
+	b.getTerms(ctx)("tags", "go", taxonomyOption{})
+	assert.Contains(t, ctx.takeDeps(), "contents/a.md")
+}
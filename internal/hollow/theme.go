@@ -3,14 +3,17 @@ package hollow
 import (
 	"encoding/json"
 	"fmt"
+	"github.com/dop251/goja"
 	"github.com/go-git/go-billy/v5"
 	"github.com/go-git/go-billy/v5/helper/chroot"
+	billyutil "github.com/go-git/go-billy/v5/util"
 	"github.com/zbysir/gojsx"
 	"github.com/zbysir/hollow/internal/pkg/asynctask"
 	git "github.com/zbysir/hollow/internal/pkg/git"
 	"github.com/zbysir/hollow/internal/pkg/gobilly"
 	"github.com/zbysir/hollow/internal/pkg/log"
 	"github.com/zbysir/hollow/internal/pkg/util"
+	"go.uber.org/zap"
 	"io/fs"
 	"os"
 	"path/filepath"
@@ -18,36 +21,155 @@ import (
 )
 
 type ThemeExport struct {
-	Pages  Pages
-	Assets Assets
+	Pages   Pages
+	Assets  Assets
+	Loaders map[string]goja.Callable // 自定义内容加载器，key 为扩展名，如 .org
+
+	vm *goja.Runtime // 执行主题的 js 运行时，用于将 go 数据（如动态路由参数）传递给主题中的函数
+}
+
+// ExpandPages 返回展开动态路由页面之后的所有页面，动态页面会调用 getPaths 得到所有参数。
+func (t ThemeExport) ExpandPages() (Pages, error) {
+	var ps Pages
+	for _, p := range t.Pages {
+		if !p.IsDynamic() {
+			ps = append(ps, p)
+			continue
+		}
+
+		params, err := p.getPaths()
+		if err != nil {
+			return nil, fmt.Errorf("getPaths of page '%v' error: %w", p.GetPath(), err)
+		}
+		for _, param := range params {
+			pa, err := fillPathPattern(p.GetPath(), param)
+			if err != nil {
+				return nil, fmt.Errorf("expand page '%v' error: %w", p.GetPath(), err)
+			}
+			ps = append(ps, p.withParams(t.vm, pa, param))
+		}
+	}
+
+	return ps, nil
+}
+
+// MatchPage 返回 reqPath 对应的页面，静态页面优先于动态页面。
+// 动态页面只有 getPaths 返回的路径才能匹配，与构建的结果保持一致，getPaths 的结果会缓存在 ctx 中。
+func (t ThemeExport) MatchPage(ctx *RenderContext, reqPath string) (Page, bool, error) {
+	for _, p := range t.Pages {
+		if !p.IsDynamic() && p.GetPath() == reqPath {
+			return p, true, nil
+		}
+	}
+
+	for i, p := range t.Pages {
+		if !p.IsDynamic() {
+			continue
+		}
+		if _, ok := matchPathPattern(p.GetPath(), reqPath); !ok {
+			continue
+		}
+
+		var params []map[string]interface{}
+		cacheKey := fmt.Sprintf("getPaths:%v:%v", i, p.GetPath())
+		if x, ok := ctx.cache.Get(cacheKey); ok {
+			params = x.([]map[string]interface{})
+		} else {
+			var err error
+			params, err = p.getPaths()
+			if err != nil {
+				return nil, false, fmt.Errorf("getPaths of page '%v' error: %w", p.GetPath(), err)
+			}
+			ctx.cache.Add(cacheKey, params)
+		}
+		for _, param := range params {
+			pa, err := fillPathPattern(p.GetPath(), param)
+			if err != nil {
+				return nil, false, fmt.Errorf("expand page '%v' error: %w", p.GetPath(), err)
+			}
+			if pa == reqPath {
+				return p.withParams(t.vm, reqPath, param), true, nil
+			}
+		}
+	}
+
+	return nil, false, nil
 }
 
 type ThemeLoader interface {
 	Load(ctx *RenderContext, refresh bool, enableAsync bool, jsxOpts ...gojsx.OptionExec) (ThemeExport, fs.FS, *asynctask.Task, error)
 }
 
+// themeFsLoader 只准备主题文件而不执行主题，用于组合多层主题
+type themeFsLoader interface {
+	LoadFs(ctx *RenderContext, refresh bool, enableAsync bool) (fs.FS, *asynctask.Task, error)
+}
+
+// loadThemeFs 使用 LoadFs 得到主题文件并执行主题
+func loadThemeFs(l themeFsLoader, ctx *RenderContext, refresh bool, enableAsync bool, jsxOpts ...gojsx.OptionExec) (ThemeExport, fs.FS, *asynctask.Task, error) {
+	f, task, err := l.LoadFs(ctx, refresh, enableAsync)
+	if err != nil || task != nil {
+		return ThemeExport{}, nil, task, err
+	}
+
+	jsx, err := gojsx.NewJsx(gojsx.Option{
+		Fs: f,
+	})
+	if err != nil {
+		return ThemeExport{}, nil, nil, err
+	}
+
+	theme, err := execTheme(jsx, "index", jsxOpts...)
+	if err != nil {
+		return ThemeExport{}, nil, nil, err
+	}
+	return theme, f, nil, nil
+}
+
 // GitThemeLoader
 // e.g. https:/github.com/zbysir/hollow-theme/tree/master/hollow/index
+// tree 之后可以是分支、标签或者提交 SHA，第一次解析后会将确切的提交记录到 hollow.lock 中，之后一直使用该提交。
 type GitThemeLoader struct {
 	asyncTask *asynctask.Manager
 	path      string
 	cacheFs   billy.Filesystem
+	lockFs    billy.Filesystem // hollow.lock 所在的文件系统，为 nil 则不锁定版本
 }
 
-func NewGitThemeLoader(asyncTask *asynctask.Manager, path string, cacheFs billy.Filesystem) *GitThemeLoader {
-	return &GitThemeLoader{asyncTask: asyncTask, path: path, cacheFs: cacheFs}
+func NewGitThemeLoader(asyncTask *asynctask.Manager, path string, cacheFs billy.Filesystem, lockFs billy.Filesystem) *GitThemeLoader {
+	return &GitThemeLoader{asyncTask: asyncTask, path: path, cacheFs: cacheFs, lockFs: lockFs}
 }
 
-// Load 会缓存 fs ，只有当强制刷新时更新
 func (g *GitThemeLoader) Load(ctx *RenderContext, refresh bool, enableAsync bool, jsxOpts ...gojsx.OptionExec) (ThemeExport, fs.FS, *asynctask.Task, error) {
+	return loadThemeFs(g, ctx, refresh, enableAsync, jsxOpts...)
+}
+
+// LoadFs 会缓存 fs ，锁定了版本时只有缓存的版本不一致才会更新，没有锁定时只有当强制刷新时更新
+func (g *GitThemeLoader) LoadFs(ctx *RenderContext, refresh bool, enableAsync bool) (fs.FS, *asynctask.Task, error) {
 	fileSys := chroot.New(g.cacheFs, "theme")
 
-	_, err := fileSys.Stat(".git")
+	_, _, subPath, err := resolveGitUrl(g.path)
 	if err != nil {
-		if os.IsNotExist(err) {
-			refresh = true
-		} else {
-			return ThemeExport{}, nil, nil, err
+		return nil, nil, err
+	}
+
+	lock, locked, err := g.readLock()
+	if err != nil {
+		return nil, nil, err
+	}
+	switch {
+	case locked:
+		refresh = gitHead(fileSys) != lock.Commit
+	case g.lockFs != nil:
+		// 第一次解析版本
+		refresh = true
+	default:
+		if _, err := fileSys.Stat(".git"); err != nil {
+			if os.IsNotExist(err) {
+				refresh = true
+			} else {
+				return nil, nil, err
+			}
 		}
 	}
 
@@ -55,12 +177,7 @@ func (g *GitThemeLoader) Load(ctx *RenderContext, refresh bool, enableAsync bool
 	// 如果有异步任务，则直接显示异步任务
 	task, exist := g.asyncTask.GetTask(taskKey)
 	if exist {
-		return ThemeExport{}, nil, task, nil
-	}
-
-	remote, branch, subPath, err := resolveGitUrl(g.path)
-	if err != nil {
-		return ThemeExport{}, nil, nil, err
+		return nil, task, nil
 	}
 
 	if refresh {
@@ -85,50 +202,75 @@ func (g *GitThemeLoader) Load(ctx *RenderContext, refresh bool, enableAsync bool
 						DisableTime:   true,
 					})
 
-					gt, err := git.NewGit("", fileSys, logger)
-					if err != nil {
-						return
-					}
-
-					err = gt.Pull(remote, branch, true)
-					if err != nil {
-						return
-					}
+					_, err = g.checkout(fileSys, lock, locked, logger)
 				}()
 			}
 
-			return ThemeExport{}, nil, task, nil
+			return nil, task, nil
 		} else {
-			gt, err := git.NewGit("", fileSys, log.Logger())
-			if err != nil {
-				return ThemeExport{}, nil, nil, nil
-			}
-
-			err = gt.Pull(remote, branch, true)
+			_, err = g.checkout(fileSys, lock, locked, log.Logger())
 			if err != nil {
-				return ThemeExport{}, nil, nil, nil
+				return nil, nil, err
 			}
 		}
 	}
 
 	subFs, err := fileSys.Chroot(subPath)
 	if err != nil {
-		return ThemeExport{}, nil, nil, err
+		return nil, nil, err
 	}
-	f := gobilly.NewStdFs(subFs)
+	return gobilly.NewStdFs(subFs), nil, nil
+}
 
-	jsx, err := gojsx.NewJsx(gojsx.Option{
-		Fs: f,
-	})
+// Update 重新解析地址中的分支或者标签，切换到最新的提交并更新 hollow.lock
+func (g *GitThemeLoader) Update(logger *zap.SugaredLogger) (ThemeLock, error) {
+	return g.checkout(chroot.New(g.cacheFs, "theme"), ThemeLock{}, false, logger)
+}
+
+func (g *GitThemeLoader) readLock() (ThemeLock, bool, error) {
+	if g.lockFs == nil {
+		return ThemeLock{}, false, nil
+	}
+	return readThemeLock(g.lockFs, g.path)
+}
+
+// checkout 切换到锁定的提交，没有锁定时切换到地址中的分支、标签或者提交并锁定
+func (g *GitThemeLoader) checkout(fileSys billy.Filesystem, lock ThemeLock, locked bool, logger *zap.SugaredLogger) (ThemeLock, error) {
+	remote, ref, _, err := resolveGitUrl(g.path)
 	if err != nil {
-		return ThemeExport{}, nil, nil, err
+		return ThemeLock{}, err
+	}
+	if locked {
+		ref = lock.Commit
 	}
 
-	theme, err := execTheme(jsx, filepath.Join("index"), jsxOpts...)
+	gt, err := git.NewGit("", fileSys, logger)
 	if err != nil {
-		return ThemeExport{}, nil, nil, err
+		return ThemeLock{}, err
 	}
-	return theme, f, nil, nil
+	commit, err := gt.Checkout(remote, ref)
+	if err != nil {
+		return ThemeLock{}, err
+	}
+
+	lock = ThemeLock{Url: g.path, Commit: commit}
+	if !locked && g.lockFs != nil {
+		err = writeThemeLock(g.lockFs, lock)
+		if err != nil {
+			return ThemeLock{}, err
+		}
+		logger.Infof("Locked theme %v at %v", g.path, commit)
+	}
+	return lock, nil
+}
+
+// gitHead 返回 fileSys 中仓库当前的提交，不存在时返回空
+func gitHead(fileSys billy.Filesystem) string {
+	bs, err := billyutil.ReadFile(fileSys, ".git/HEAD")
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(bs))
 }
 
 // https://github.com/zbysir/hollow-theme/tree/master/hollow
@@ -161,27 +303,27 @@ func NewFsThemeLoader(rootFs fs.FS) *LocalThemeLoader {
 }
 
 func (l *LocalThemeLoader) Load(ctx *RenderContext, refresh bool, enableAsync bool, jsxOpts ...gojsx.OptionExec) (ThemeExport, fs.FS, *asynctask.Task, error) {
-	jsx, err := gojsx.NewJsx(gojsx.Option{
-		Fs: l.f,
-	})
-	if err != nil {
-		return ThemeExport{}, nil, nil, err
-	}
-	theme, err := execTheme(jsx, "index", jsxOpts...)
-	if err != nil {
-		return ThemeExport{}, nil, nil, err
-	}
+	return loadThemeFs(l, ctx, refresh, enableAsync, jsxOpts...)
+}
 
-	return theme, l.f, nil, nil
+func (l *LocalThemeLoader) LoadFs(ctx *RenderContext, refresh bool, enableAsync bool) (fs.FS, *asynctask.Task, error) {
+	return l.f, nil, nil
 }
 
 func execTheme(jsx *gojsx.Jsx, configFile string, jsxOpts ...gojsx.OptionExec) (ThemeExport, error) {
 	envBs, _ := json.Marshal(nil)
 	processCode := fmt.Sprintf("var process = {env: %s}", envBs)
 
+	// gojsx 没有暴露运行时，通过调用 go 函数获取
+	var vm *goja.Runtime
+	jsxOpts = append(jsxOpts, gojsx.WithGlobalVar("__hollowRuntime", func(call goja.FunctionCall, r *goja.Runtime) goja.Value {
+		vm = r
+		return goja.Undefined()
+	}))
+
 	// 添加 ./ 告知 module 加载项目文件而不是 node_module
 	configFile = "./" + filepath.Clean(configFile)
-	code := fmt.Sprintf(`%s; module.exports = require("%s")`, processCode, configFile)
+	code := fmt.Sprintf(`%s; __hollowRuntime(); module.exports = require("%s")`, processCode, configFile)
 	v, err := jsx.ExecCode([]byte(code), jsxOpts...)
 	if err != nil {
 		return ThemeExport{}, err
@@ -200,6 +342,19 @@ func execTheme(jsx *gojsx.Jsx, configFile string, jsxOpts ...gojsx.OptionExec) (
 	for k, v := range as {
 		assets[k] = exportGojaValueToString(v)
 	}
+	loaders := map[string]goja.Callable{}
+	if ls, ok := raw["loaders"].(map[string]interface{}); ok {
+		for ext, l := range ls {
+			var c goja.Callable
+			if o, ok := l.(*goja.Object); ok {
+				c, _ = gojsx.AssertFunction(o)
+			}
+			if c == nil {
+				return ThemeExport{}, fmt.Errorf("loader of '%v' must be a function", ext)
+			}
+			loaders[ext] = c
+		}
+	}
 	configDir := filepath.Dir(configFile)
 
 	for i, a := range assets {
@@ -208,5 +363,5 @@ func execTheme(jsx *gojsx.Jsx, configFile string, jsxOpts ...gojsx.OptionExec) (
 		assets[i] = dir
 	}
 
-	return ThemeExport{Pages: ps, Assets: assets}, nil
+	return ThemeExport{Pages: ps, Assets: assets, Loaders: loaders, vm: vm}, nil
 }
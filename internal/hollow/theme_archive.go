@@ -0,0 +1,311 @@
+package hollow
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/go-git/go-billy/v5"
+	"github.com/go-git/go-billy/v5/helper/chroot"
+	billyutil "github.com/go-git/go-billy/v5/util"
+	"github.com/zbysir/gojsx"
+	"github.com/zbysir/hollow/internal/pkg/asynctask"
+	"github.com/zbysir/hollow/internal/pkg/gobilly"
+	"github.com/zbysir/hollow/internal/pkg/log"
+	"github.com/zbysir/hollow/internal/pkg/util"
+	"go.uber.org/zap"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+)
+
+// archiveHashFile 记录已解压的压缩包的 sha256，用于判断缓存是否可用
+const archiveHashFile = ".hollow-archive"
+
+// archiveClient 用于下载压缩包，服务端没有响应时不会一直等待
+var archiveClient = &http.Client{Timeout: 5 * time.Minute}
+
+// ArchiveThemeLoader 从 http 下载 .zip 或者 .tar.gz 格式的主题，解压到 CacheFs 中
+// e.g. https://example.com/hollow-theme-v1.0.0.zip#hollow ，# 之后为主题在压缩包中的路径，可以省略，
+// 省略时如果压缩包中只有一个文件夹（如 github release），则使用该文件夹。
+type ArchiveThemeLoader struct {
+	asyncTask *asynctask.Manager
+	url       string
+	sha256    string // 期望的压缩包 sha256，为空则不校验
+	cacheFs   billy.Filesystem
+}
+
+func NewArchiveThemeLoader(asyncTask *asynctask.Manager, url string, sha256 string, cacheFs billy.Filesystem) *ArchiveThemeLoader {
+	return &ArchiveThemeLoader{asyncTask: asyncTask, url: url, sha256: strings.ToLower(sha256), cacheFs: cacheFs}
+}
+
+// isArchiveUrl 返回地址是否是 http(s) 下载的 .zip 或者 .tar.gz 格式的压缩包
+func isArchiveUrl(u string) bool {
+	if !strings.HasPrefix(u, "http://") && !strings.HasPrefix(u, "https://") {
+		return false
+	}
+	_, ext, err := resolveArchiveUrl(u)
+	return err == nil && ext != ""
+}
+
+// resolveArchiveUrl 返回去掉 # 之后部分的下载地址与压缩包格式（.zip / .tar.gz），不是压缩包时 ext 为空
+func resolveArchiveUrl(u string) (download string, ext string, err error) {
+	pu, err := url.Parse(u)
+	if err != nil {
+		return "", "", err
+	}
+	pu.Fragment = ""
+	switch p := strings.ToLower(pu.Path); {
+	case strings.HasSuffix(p, ".zip"):
+		ext = ".zip"
+	case strings.HasSuffix(p, ".tar.gz"), strings.HasSuffix(p, ".tgz"):
+		ext = ".tar.gz"
+	}
+	return pu.String(), ext, nil
+}
+
+func (a *ArchiveThemeLoader) Load(ctx *RenderContext, refresh bool, enableAsync bool, jsxOpts ...gojsx.OptionExec) (ThemeExport, fs.FS, *asynctask.Task, error) {
+	return loadThemeFs(a, ctx, refresh, enableAsync, jsxOpts...)
+}
+
+// LoadFs 会缓存解压后的文件，只有当强制刷新时重新下载；如果配置了 sha256 且与缓存一致，则不会重新下载
+func (a *ArchiveThemeLoader) LoadFs(ctx *RenderContext, refresh bool, enableAsync bool) (fs.FS, *asynctask.Task, error) {
+	fileSys := chroot.New(a.cacheFs, path.Join("theme-archive", util.MD5(a.url)))
+
+	cached, _ := billyutil.ReadFile(fileSys, archiveHashFile)
+	switch {
+	case len(cached) == 0:
+		refresh = true
+	case a.sha256 != "":
+		refresh = string(cached) != a.sha256
+	}
+
+	taskKey := util.MD5(a.url)
+	task, exist := a.asyncTask.GetTask(taskKey)
+	if exist {
+		return nil, task, nil
+	}
+
+	if refresh {
+		if enableAsync {
+			task, isNew := a.asyncTask.NewTask(taskKey)
+			if isNew {
+				go func() {
+					logger := log.New(log.Options{
+						IsDev:         false,
+						To:            task,
+						DisableCaller: true,
+						CallerSkip:    0,
+						Name:          "",
+						DisableTime:   true,
+					})
+					err := a.fetch(fileSys, logger)
+					if err != nil {
+						task.Log("error: " + err.Error())
+					}
+					task.Done()
+				}()
+			}
+			return nil, task, nil
+		}
+
+		err := a.fetch(fileSys, log.Logger())
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	themeFs, err := a.themeFs(fileSys)
+	if err != nil {
+		return nil, nil, err
+	}
+	return gobilly.NewStdFs(themeFs), nil, nil
+}
+
+// themeFs 返回主题所在的文件夹
+func (a *ArchiveThemeLoader) themeFs(fileSys billy.Filesystem) (billy.Filesystem, error) {
+	pu, err := url.Parse(a.url)
+	if err != nil {
+		return nil, err
+	}
+	if sub := strings.Trim(pu.Fragment, "/"); sub != "" {
+		return fileSys.Chroot(sub)
+	}
+
+	fis, err := fileSys.ReadDir("")
+	if err != nil {
+		return nil, err
+	}
+	var entries []fs.FileInfo
+	for _, fi := range fis {
+		if fi.Name() != archiveHashFile {
+			entries = append(entries, fi)
+		}
+	}
+	if len(entries) == 1 && entries[0].IsDir() {
+		return fileSys.Chroot(entries[0].Name())
+	}
+	return fileSys, nil
+}
+
+// fetch 下载并校验压缩包，然后解压到 fileSys 中
+func (a *ArchiveThemeLoader) fetch(fileSys billy.Filesystem, logger *zap.SugaredLogger) error {
+	download, ext, err := resolveArchiveUrl(a.url)
+	if err != nil {
+		return err
+	}
+	logger.Infof("Downloading %v", download)
+
+	// 下载到 CacheFs 中的临时文件，同时计算 sha256，不需要将整个压缩包读入内存
+	tmp := path.Join("theme-archive", util.MD5(a.url)+".download")
+	defer a.cacheFs.Remove(tmp)
+	hash, size, err := a.download(download, tmp)
+	if err != nil {
+		return fmt.Errorf("download theme '%v' error: %w", download, err)
+	}
+	if a.sha256 != "" && a.sha256 != hash {
+		return fmt.Errorf("sha256 of theme '%v' mismatch, expected %v, actual %v", download, a.sha256, hash)
+	}
+	body, err := a.cacheFs.Open(tmp)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	// 清空上一个版本
+	fis, _ := fileSys.ReadDir("")
+	for _, fi := range fis {
+		if err = billyutil.RemoveAll(fileSys, fi.Name()); err != nil {
+			return err
+		}
+	}
+	switch ext {
+	case ".zip":
+		err = unzip(body, size, fileSys)
+	case ".tar.gz":
+		err = untar(body, fileSys)
+	default:
+		err = fmt.Errorf("unsupported archive: %v", download)
+	}
+	if err != nil {
+		return fmt.Errorf("unpack theme '%v' error: %w", download, err)
+	}
+
+	err = billyutil.WriteFile(fileSys, archiveHashFile, []byte(hash), 0644)
+	if err != nil {
+		return err
+	}
+	logger.Infof("Unpacked theme, sha256: %v", hash)
+	return nil
+}
+
+// download 将 download 下载到 CacheFs 中的 dst 文件，返回文件的 sha256 与大小
+func (a *ArchiveThemeLoader) download(download string, dst string) (hash string, size int64, err error) {
+	rsp, err := archiveClient.Get(download)
+	if err != nil {
+		return "", 0, err
+	}
+	defer rsp.Body.Close()
+	if rsp.StatusCode != http.StatusOK {
+		return "", 0, fmt.Errorf("%v", rsp.Status)
+	}
+
+	f, err := a.cacheFs.Create(dst)
+	if err != nil {
+		return "", 0, err
+	}
+	defer f.Close()
+	h := sha256.New()
+	size, err = io.Copy(io.MultiWriter(f, h), rsp.Body)
+	if err != nil {
+		return "", 0, err
+	}
+	return hex.EncodeToString(h.Sum(nil)), size, nil
+}
+
+// archivePath 返回压缩包中文件的相对路径，拒绝 ../ 等逃逸到目标文件夹之外的路径
+func archivePath(name string) (string, error) {
+	p := path.Clean(strings.ReplaceAll(name, "\\", "/"))
+	if path.IsAbs(p) || p == ".." || strings.HasPrefix(p, "../") {
+		return "", fmt.Errorf("illegal file path in archive: %v", name)
+	}
+	return p, nil
+}
+
+func unzip(body io.ReaderAt, size int64, dst billy.Filesystem) error {
+	r, err := zip.NewReader(body, size)
+	if err != nil {
+		return err
+	}
+	for _, f := range r.File {
+		name, err := archivePath(f.Name)
+		if err != nil {
+			return err
+		}
+		if f.FileInfo().IsDir() {
+			if err = dst.MkdirAll(name, 0755); err != nil {
+				return err
+			}
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return err
+		}
+		err = writeArchiveFile(dst, name, rc)
+		rc.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func untar(body io.Reader, dst billy.Filesystem) error {
+	gz, err := gzip.NewReader(body)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+
+	r := tar.NewReader(gz)
+	for {
+		h, err := r.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		name, err := archivePath(h.Name)
+		if err != nil {
+			return err
+		}
+		switch h.Typeflag {
+		case tar.TypeDir:
+			if err = dst.MkdirAll(name, 0755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err = writeArchiveFile(dst, name, r); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func writeArchiveFile(dst billy.Filesystem, name string, r io.Reader) error {
+	f, err := dst.Create(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(f, r)
+	return err
+}
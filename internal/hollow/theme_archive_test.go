@@ -0,0 +1,165 @@
+package hollow
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/go-git/go-billy/v5/util"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+var archiveTheme = map[string]string{
+	"theme-1.0/index.jsx":        `export default {pages: [{path: "", component: () => <div>archive</div>}], assets: ["statics"]}`,
+	"theme-1.0/statics/main.css": "body {}",
+}
+
+func zipArchive(t *testing.T, files map[string]string) []byte {
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for name, body := range files {
+		f, err := w.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.Write([]byte(body))
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func tarGzArchive(t *testing.T, files map[string]string) []byte {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	w := tar.NewWriter(gz)
+	for name, body := range files {
+		err := w.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(body)), Typeflag: tar.TypeReg})
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(body))
+	}
+	w.Close()
+	gz.Close()
+	return buf.Bytes()
+}
+
+func TestArchiveThemeLoader(t *testing.T) {
+	archives := map[string][]byte{
+		"/theme.zip":    zipArchive(t, archiveTheme),
+		"/theme.tar.gz": tarGzArchive(t, archiveTheme),
+		"/evil.zip":     zipArchive(t, map[string]string{"../evil.jsx": ""}),
+	}
+	downloads := 0
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/stalled.zip" {
+			w.Write([]byte("PK"))
+			w.(http.Flusher).Flush()
+			// 直到客户端超时断开
+			<-r.Context().Done()
+			return
+		}
+		bs, ok := archives[r.URL.Path]
+		if !ok {
+			w.WriteHeader(404)
+			return
+		}
+		downloads++
+		w.Write(bs)
+	}))
+	defer s.Close()
+
+	for _, name := range []string{"/theme.zip", "/theme.tar.gz"} {
+		t.Run(name, func(t *testing.T) {
+			downloads = 0
+			sum := sha256.Sum256(archives[name])
+
+			source := memfs.New()
+			writeTestFile(t, source, "config.yml", "theme: "+s.URL+name+"\ntheme_sha256: "+hex.EncodeToString(sum[:])+"\n")
+			b, err := NewHollow(Option{SourceFs: source})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for i := 0; i < 2; i++ {
+				dst := memfs.New()
+				err = b.BuildToFs(NewRenderContext(), dst, ExecOption{})
+				if err != nil {
+					t.Fatal(err)
+				}
+				bs, _ := util.ReadFile(dst, "index.html")
+				assert.Equal(t, "<div>archive</div>", string(bs))
+				assert.True(t, existFile(dst, "main.css"))
+			}
+			// 配置了 sha256 时使用缓存
+			assert.Equal(t, 1, downloads)
+		})
+	}
+
+	// 没有配置 sha256 时也使用缓存，只有 RefreshTheme 时重新下载
+	downloads = 0
+	source := memfs.New()
+	writeTestFile(t, source, "config.yml", "theme: "+s.URL+"/theme.zip\n")
+	b, err := NewHollow(Option{SourceFs: source})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, refresh := range []bool{false, false, true} {
+		err = b.BuildToFs(NewRenderContext(), memfs.New(), ExecOption{RefreshTheme: refresh})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	assert.Equal(t, 2, downloads)
+	// 下载的临时文件会被删除
+	fis, err := b.CacheFs.ReadDir("theme-archive")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if assert.Len(t, fis, 1) {
+		assert.True(t, fis[0].IsDir())
+	}
+
+	source = memfs.New()
+	writeTestFile(t, source, "config.yml", "theme: "+s.URL+"/theme.zip\ntheme_sha256: 1234\n")
+	b, err = NewHollow(Option{SourceFs: source})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = b.BuildToFs(NewRenderContext(), memfs.New(), ExecOption{})
+	assert.ErrorContains(t, err, "sha256 of theme '"+s.URL+"/theme.zip' mismatch")
+
+	writeTestFile(t, source, "config.yml", "theme: "+s.URL+"/evil.zip\n")
+	err = b.BuildToFs(NewRenderContext(), memfs.New(), ExecOption{})
+	assert.ErrorContains(t, err, "illegal file path in archive: ../evil.jsx")
+
+	writeTestFile(t, source, "config.yml", "theme: "+s.URL+"/missing.tgz\n")
+	err = b.BuildToFs(NewRenderContext(), memfs.New(), ExecOption{})
+	assert.ErrorContains(t, err, "404 Not Found")
+
+	// 服务端没有响应时超时
+	timeout := archiveClient.Timeout
+	archiveClient.Timeout = 200 * time.Millisecond
+	defer func() { archiveClient.Timeout = timeout }()
+	writeTestFile(t, source, "config.yml", "theme: "+s.URL+"/stalled.zip\n")
+	err = b.BuildToFs(NewRenderContext(), memfs.New(), ExecOption{})
+	assert.ErrorContains(t, err, "download theme '"+s.URL+"/stalled.zip' error")
+}
+
+func TestIsArchiveUrl(t *testing.T) {
+	assert.True(t, isArchiveUrl("https://example.com/a/theme.zip"))
+	assert.True(t, isArchiveUrl("https://example.com/theme.tar.gz#hollow"))
+	assert.True(t, isArchiveUrl("https://example.com/theme.TGZ?token=1"))
+	assert.False(t, isArchiveUrl("https://github.com/zbysir/hollow-theme/tree/master/hollow"))
+	assert.False(t, isArchiveUrl("file://./theme.zip"))
+	assert.False(t, isArchiveUrl("source://themes/theme.tar.gz"))
+}
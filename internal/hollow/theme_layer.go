@@ -0,0 +1,129 @@
+package hollow
+
+import (
+	"errors"
+	"github.com/zbysir/gojsx"
+	"github.com/zbysir/hollow/internal/pkg/asynctask"
+	"io/fs"
+	"sort"
+	"strings"
+)
+
+// themeLayerSep 连接多层主题的地址，地址中不会出现空格
+const themeLayerSep = " "
+
+// splitThemeLayers 返回多层主题的所有地址，基础主题在前
+func splitThemeLayers(url string) []string {
+	return strings.Fields(url)
+}
+
+// LayerThemeLoader 组合多层主题，后面的主题中的文件会覆盖前面的主题中的同名文件，
+// 如 [git 基础主题, source://theme-overrides]，require('./components/Footer') 会优先使用 theme-overrides 中的文件。
+type LayerThemeLoader struct {
+	layers []themeFsLoader
+}
+
+func NewLayerThemeLoader(layers []themeFsLoader) *LayerThemeLoader {
+	return &LayerThemeLoader{layers: layers}
+}
+
+func (l *LayerThemeLoader) Load(ctx *RenderContext, refresh bool, enableAsync bool, jsxOpts ...gojsx.OptionExec) (ThemeExport, fs.FS, *asynctask.Task, error) {
+	return loadThemeFs(l, ctx, refresh, enableAsync, jsxOpts...)
+}
+
+func (l *LayerThemeLoader) LoadFs(ctx *RenderContext, refresh bool, enableAsync bool) (fs.FS, *asynctask.Task, error) {
+	fss := make(layerFs, len(l.layers))
+	for i, layer := range l.layers {
+		f, task, err := layer.LoadFs(ctx, refresh, enableAsync)
+		if err != nil || task != nil {
+			return nil, task, err
+		}
+		fss[i] = f
+	}
+	return fss, nil, nil
+}
+
+// layerFs 合并多个 fs，打开文件时优先使用后面的 fs，读取文件夹时合并所有 fs 中的文件
+type layerFs []fs.FS
+
+func (l layerFs) Open(name string) (fs.File, error) {
+	var firstErr error
+	for i := len(l) - 1; i >= 0; i-- {
+		f, err := l[i].Open(name)
+		if err == nil {
+			return f, nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) && firstErr == nil {
+			firstErr = err
+		}
+	}
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+}
+
+func (l layerFs) ReadDir(name string) ([]fs.DirEntry, error) {
+	entries := map[string]fs.DirEntry{}
+	found := false
+	for i := len(l) - 1; i >= 0; i-- {
+		es, err := fs.ReadDir(l[i], name)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return nil, err
+		}
+		found = true
+		for _, e := range es {
+			if _, ok := entries[e.Name()]; !ok {
+				entries[e.Name()] = e
+			}
+		}
+	}
+	if !found {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+
+	list := make([]fs.DirEntry, 0, len(entries))
+	for _, e := range entries {
+		list = append(list, e)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Name() < list[j].Name()
+	})
+	return list, nil
+}
+
+func (l layerFs) Stat(name string) (fs.FileInfo, error) {
+	var firstErr error
+	for i := len(l) - 1; i >= 0; i-- {
+		fi, err := fs.Stat(l[i], name)
+		if err == nil {
+			return fi, nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) && firstErr == nil {
+			firstErr = err
+		}
+	}
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrNotExist}
+}
+
+// Sub 返回合并了所有 fs 中 dir 文件夹的 fs，用于 ServiceHandle 中的主题静态文件
+func (l layerFs) Sub(dir string) (fs.FS, error) {
+	var subs layerFs
+	for _, f := range l {
+		if fi, err := fs.Stat(f, dir); err != nil || !fi.IsDir() {
+			continue
+		}
+		s, err := fs.Sub(f, dir)
+		if err != nil {
+			return nil, err
+		}
+		subs = append(subs, s)
+	}
+	return subs, nil
+}
@@ -0,0 +1,73 @@
+package hollow
+
+import (
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/go-git/go-billy/v5/util"
+	"github.com/stretchr/testify/assert"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestThemeLayers(t *testing.T) {
+	source := memfs.New()
+	writeTestFile(t, source, "config.yml", "themes: [base, overrides]\n")
+	writeTestFile(t, source, "base/index.jsx", `
+import Header from "./components/Header"
+import Footer from "./components/Footer"
+
+export default {
+  pages: [{path: "", component: () => <div><Header/><Footer/></div>}],
+  assets: ["statics"],
+}
+`)
+	writeTestFile(t, source, "base/components/Header.jsx", `export default () => <header>base</header>`)
+	writeTestFile(t, source, "base/components/Footer.jsx", `export default () => <footer>base</footer>`)
+	writeTestFile(t, source, "base/statics/main.css", "base")
+	writeTestFile(t, source, "base/statics/base.css", "base")
+	writeTestFile(t, source, "overrides/components/Footer.jsx", `export default () => <footer>override</footer>`)
+	writeTestFile(t, source, "overrides/statics/main.css", "override")
+
+	b, err := NewHollow(Option{SourceFs: source})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dst := memfs.New()
+	err = b.BuildToFs(NewRenderContext(), dst, ExecOption{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, body := range map[string]string{
+		"index.html": "<div><header>base</header><footer>override</footer></div>",
+		"main.css":   "override",
+		"base.css":   "base",
+	} {
+		bs, err := util.ReadFile(dst, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, body, string(bs))
+	}
+
+	handle := b.ServiceHandle(ExecOption{IsDev: true})
+	for path, body := range map[string]string{
+		"/main.css": "override",
+		"/base.css": "base",
+	} {
+		w := httptest.NewRecorder()
+		handle(w, httptest.NewRequest("GET", path, nil))
+		assert.Equal(t, 200, w.Code)
+		assert.Equal(t, body, w.Body.String())
+	}
+}
+
+func TestThemeLayersRemote(t *testing.T) {
+	source := memfs.New()
+	writeTestFile(t, source, "config.yml", "themes:\n  - https://example.com/base.zip\n  - https://github.com/zbysir/hollow-theme/tree/master/hollow\n  - overrides\n")
+	b, err := NewHollow(Option{SourceFs: source})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = b.BuildToFs(NewRenderContext(), memfs.New(), ExecOption{})
+	assert.ErrorContains(t, err, "only one remote theme (git or archive) can be used in themes")
+}
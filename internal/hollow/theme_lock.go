@@ -0,0 +1,56 @@
+package hollow
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/go-git/go-billy/v5"
+	billyutil "github.com/go-git/go-billy/v5/util"
+	"os"
+)
+
+// themeLockFile 记录 git 主题的确切提交，保存在源文件根目录，构建时会一直使用该提交直到运行 hollow theme update
+const themeLockFile = "hollow.lock"
+
+// ThemeLock 是 git 主题被锁定的版本
+type ThemeLock struct {
+	Url    string `json:"url"`    // 主题地址，地址变化后会重新锁定
+	Commit string `json:"commit"` // 提交 SHA
+}
+
+type lockFile struct {
+	Theme ThemeLock `json:"theme"`
+}
+
+// readThemeLock 读取 url 对应的锁定版本，文件不存在或者地址不一致时返回 false
+func readThemeLock(f billy.Filesystem, url string) (ThemeLock, bool, error) {
+	bs, err := billyutil.ReadFile(f, themeLockFile)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return ThemeLock{}, false, nil
+		}
+		return ThemeLock{}, false, err
+	}
+
+	var l lockFile
+	err = json.Unmarshal(bs, &l)
+	if err != nil {
+		return ThemeLock{}, false, fmt.Errorf("parse %v error: %w", themeLockFile, err)
+	}
+	if l.Theme.Url != url || l.Theme.Commit == "" {
+		return ThemeLock{}, false, nil
+	}
+	return l.Theme, true, nil
+}
+
+func writeThemeLock(f billy.Filesystem, lock ThemeLock) error {
+	bs, err := json.MarshalIndent(lockFile{Theme: lock}, "", "  ")
+	if err != nil {
+		return err
+	}
+	err = billyutil.WriteFile(f, themeLockFile, append(bs, '\n'), 0644)
+	if err != nil {
+		return fmt.Errorf("write %v error: %w", themeLockFile, err)
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package hollow
+
+import (
+	"encoding/json"
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/go-git/go-billy/v5/util"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// commitTheme 提交一个只有首页的主题，返回提交 SHA
+func commitTheme(t *testing.T, r *git.Repository, dir string, body string) string {
+	err := os.WriteFile(filepath.Join(dir, "index.jsx"), []byte(`export default {pages: [{path: "", component: () => <div>`+body+`</div>}], assets: []}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wt, err := r.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = wt.Add("index.jsx"); err != nil {
+		t.Fatal(err)
+	}
+	h, err := wt.Commit(body, &git.CommitOptions{Author: &object.Signature{Name: "test", When: time.Now()}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return h.String()
+}
+
+func TestThemeLock(t *testing.T) {
+	if _, err := exec.LookPath("git-upload-pack"); err != nil {
+		t.Skip("git-upload-pack not found")
+	}
+
+	repoDir := t.TempDir()
+	r, err := git.PlainInit(repoDir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v1 := commitTheme(t, r, repoDir, "v1")
+	if _, err = r.CreateTag("v1", plumbing.NewHash(v1), &git.CreateTagOptions{Message: "v1", Tagger: &object.Signature{Name: "test", When: time.Now()}}); err != nil {
+		t.Fatal(err)
+	}
+	v2 := commitTheme(t, r, repoDir, "v2")
+
+	source := memfs.New()
+	b, err := NewHollow(Option{SourceFs: source})
+	if err != nil {
+		t.Fatal(err)
+	}
+	render := func(url string) string {
+		l := NewGitThemeLoader(b.asyncTask, url, b.CacheFs, source)
+		theme, _, _, err := l.Load(NewRenderContext(), true, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, err := theme.Pages[0].Render()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return body
+	}
+	lock := func() ThemeLock {
+		bs, err := util.ReadFile(source, themeLockFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var f lockFile
+		if err = json.Unmarshal(bs, &f); err != nil {
+			t.Fatal(err)
+		}
+		return f.Theme
+	}
+
+	// 标签、分支与提交 SHA
+	assert.Equal(t, "<div>v1</div>", render(repoDir+"/tree/v1"))
+	assert.Equal(t, ThemeLock{Url: repoDir + "/tree/v1", Commit: v1}, lock())
+	assert.Equal(t, "<div>v1</div>", render(repoDir+"/tree/"+v1[:7]))
+	assert.Equal(t, ThemeLock{Url: repoDir + "/tree/" + v1[:7], Commit: v1}, lock())
+
+	url := repoDir + "/tree/master"
+	assert.Equal(t, "<div>v2</div>", render(url))
+	assert.Equal(t, ThemeLock{Url: url, Commit: v2}, lock())
+
+	// 新的提交不会影响已锁定的版本
+	v3 := commitTheme(t, r, repoDir, "v3")
+	assert.Equal(t, "<div>v2</div>", render(url))
+
+	l, err := NewGitThemeLoader(b.asyncTask, url, b.CacheFs, source).Update(b.log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, ThemeLock{Url: url, Commit: v3}, l)
+	assert.Equal(t, ThemeLock{Url: url, Commit: v3}, lock())
+	assert.Equal(t, "<div>v3</div>", render(url))
+}
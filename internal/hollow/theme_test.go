@@ -1,7 +1,10 @@
 package hollow
 
 import (
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/go-git/go-billy/v5/util"
 	"github.com/stretchr/testify/assert"
+	"net/http/httptest"
 	"testing"
 )
 
@@ -31,3 +34,86 @@ func TestResolveGitUrl(t *testing.T) {
 		assert.Error(t, err)
 	}
 }
+
+func TestDynamicPages(t *testing.T) {
+	source := memfs.New()
+	writeTestFile(t, source, "config.yml", "theme: theme\n")
+	writeTestFile(t, source, "contents/a.md", "# a")
+	writeTestFile(t, source, "contents/b.md", "# b")
+	writeTestFile(t, source, "theme/index.jsx", `
+import {getContents, getContentDetail} from "@bysir/hollow"
+
+const Post = (props) => {
+  const c = getContentDetail("contents/" + props.slug + ".md")
+  return <div dangerouslySetInnerHTML={{__html: c.content}}></div>
+}
+
+export default {
+  pages: [
+    {path: "posts/new", body: "new"},
+    {
+      path: "posts/:slug",
+      getPaths: () => getContents("contents").list.map(i => ({slug: i.name})),
+      component: (params) => <Post slug={params.slug}/>,
+    },
+  ],
+  assets: [],
+}
+`)
+
+	b, err := NewHollow(Option{SourceFs: source})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dst := memfs.New()
+	err = b.BuildToFs(NewRenderContext(), dst, ExecOption{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, body := range map[string]string{
+		"posts/new/index.html": "new",
+		"posts/a/index.html":   `<div><h1 id="a">a</h1></div>`,
+		"posts/b/index.html":   `<div><h1 id="b">b</h1></div>`,
+	} {
+		bs, err := util.ReadFile(dst, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, body, string(bs))
+	}
+
+	handle := b.ServiceHandle(ExecOption{IsDev: true})
+	for path, body := range map[string]string{
+		"/posts/new": "new",
+		"/posts/b/":  `<div><h1 id="b">b</h1></div>`,
+	} {
+		w := httptest.NewRecorder()
+		handle(w, httptest.NewRequest("GET", path, nil))
+		assert.Equal(t, 200, w.Code)
+		assert.Equal(t, body, w.Body.String())
+	}
+
+	// getPaths 没有返回的路径不存在
+	w := httptest.NewRecorder()
+	handle(w, httptest.NewRequest("GET", "/posts/c", nil))
+	assert.Equal(t, 404, w.Code)
+}
+
+func TestMatchPathPattern(t *testing.T) {
+	p, ok := matchPathPattern("posts/:slug", "posts/hello")
+	assert.True(t, ok)
+	assert.Equal(t, map[string]interface{}{"slug": "hello"}, p)
+
+	_, ok = matchPathPattern("posts/:slug", "posts/hello/world")
+	assert.False(t, ok)
+	_, ok = matchPathPattern("posts/:slug", "tags/hello")
+	assert.False(t, ok)
+
+	s, err := fillPathPattern("/:lang/posts/:slug", map[string]interface{}{"lang": "en", "slug": 1})
+	assert.NoError(t, err)
+	assert.Equal(t, "en/posts/1", s)
+
+	_, err = fillPathPattern("posts/:slug", map[string]interface{}{})
+	assert.Error(t, err)
+}
@@ -13,6 +13,10 @@ func DeclareFlag(v *viper.Viper, c *cobra.Command, name string, shorthand string
 	switch defaultVal := defaultVal.(type) {
 	case string:
 		flags.StringP(name, shorthand, defaultVal, usage)
+	case bool:
+		flags.BoolP(name, shorthand, defaultVal, usage)
+	case int:
+		flags.IntP(name, shorthand, defaultVal, usage)
 	}
 
 	err := v.BindPFlag(name, flags.Lookup(name))
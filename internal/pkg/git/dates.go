@@ -0,0 +1,151 @@
+package git
+
+import (
+	"fmt"
+	"github.com/go-git/go-billy/v5"
+	"github.com/go-git/go-billy/v5/osfs"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/cache"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage/filesystem"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// isOsFs 返回 f 是否是本地文件系统，osfs.New 返回的 fs 包装了 chroot 与 polyfill
+func isOsFs(f billy.Basic) bool {
+	for {
+		switch t := f.(type) {
+		case *osfs.OS:
+			return true
+		case interface{ Underlying() billy.Basic }:
+			f = t.Underlying()
+		default:
+			return false
+		}
+	}
+}
+
+// FileDate 是文件第一次与最后一次提交的时间
+type FileDate struct {
+	Created time.Time
+	Updated time.Time
+}
+
+// openRepo 打开 dir 所在的仓库，返回 dir 相对于仓库根目录的路径。
+// 本地文件系统会向上查找 .git 文件夹，所以 dir 可以是仓库中的子文件夹（如 docs）。
+func openRepo(dir billy.Filesystem) (*git.Repository, string, error) {
+	if isOsFs(dir) {
+		root, err := filepath.Abs(dir.Root())
+		if err != nil {
+			return nil, "", err
+		}
+		r, err := git.PlainOpenWithOptions(root, &git.PlainOpenOptions{DetectDotGit: true})
+		if err != nil {
+			return nil, "", err
+		}
+		wt, err := r.Worktree()
+		if err != nil {
+			return nil, "", err
+		}
+		rel, err := filepath.Rel(wt.Filesystem.Root(), root)
+		if err != nil {
+			return nil, "", err
+		}
+		rel = filepath.ToSlash(rel)
+		if rel == "." {
+			rel = ""
+		}
+		return r, rel, nil
+	}
+
+	if _, err := dir.Stat(".git"); err != nil {
+		return nil, "", git.ErrRepositoryNotExists
+	}
+	dot, err := dir.Chroot(".git")
+	if err != nil {
+		return nil, "", err
+	}
+	r, err := git.Open(filesystem.NewStorage(dot, cache.NewObjectLRUDefault()), dir)
+	if err != nil {
+		return nil, "", err
+	}
+	return r, "", nil
+}
+
+// FileDates 只遍历一次 HEAD 的提交历史，返回 dir 中所有提交过的文件的创建与更新时间（作者时间），key 为相对于 dir 的路径。
+// dir 不在 git 仓库中时返回 git.ErrRepositoryNotExists。
+func FileDates(dir billy.Filesystem) (map[string]FileDate, error) {
+	r, prefix, err := openRepo(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	dates := map[string]FileDate{}
+	head, err := r.Head()
+	if err != nil {
+		if err == plumbing.ErrReferenceNotFound {
+			// 还没有提交
+			return dates, nil
+		}
+		return nil, err
+	}
+
+	commits, err := r.Log(&git.LogOptions{From: head.Hash()})
+	if err != nil {
+		return nil, fmt.Errorf("git log error: %w", err)
+	}
+	err = commits.ForEach(func(c *object.Commit) error {
+		tree, err := c.Tree()
+		if err != nil {
+			return err
+		}
+		// 第一个提交与空的 tree 比较，合并提交只与第一个父提交比较
+		parent := &object.Tree{}
+		if c.NumParents() != 0 {
+			p, err := c.Parent(0)
+			if err != nil {
+				return err
+			}
+			parent, err = p.Tree()
+			if err != nil {
+				return err
+			}
+		}
+		changes, err := parent.Diff(tree)
+		if err != nil {
+			return err
+		}
+
+		when := c.Author.When
+		for _, change := range changes {
+			name := change.To.Name
+			if name == "" {
+				// 删除的文件
+				continue
+			}
+			if prefix != "" {
+				if !strings.HasPrefix(name, prefix+"/") {
+					continue
+				}
+				name = strings.TrimPrefix(name, prefix+"/")
+			}
+
+			d, ok := dates[name]
+			if !ok || when.Before(d.Created) {
+				d.Created = when
+			}
+			if !ok || when.After(d.Updated) {
+				d.Updated = when
+			}
+			dates[name] = d
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("walk git history error: %w", err)
+	}
+	return dates, nil
+}
@@ -0,0 +1,79 @@
+package git
+
+import (
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/go-git/go-billy/v5/osfs"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFileDates(t *testing.T) {
+	dir := t.TempDir()
+	r, err := git.PlainInit(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wt, err := r.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	commit := func(when time.Time, files map[string]string) {
+		for name, body := range files {
+			p := filepath.Join(dir, name)
+			if body == "" {
+				if _, err := wt.Remove(name); err != nil {
+					t.Fatal(err)
+				}
+				continue
+			}
+			if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(p, []byte(body), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := wt.Add(name); err != nil {
+				t.Fatal(err)
+			}
+		}
+		_, err := wt.Commit("update", &git.CommitOptions{Author: &object.Signature{Name: "test", When: when}})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t1 := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	t2 := time.Date(2022, 2, 1, 0, 0, 0, 0, time.UTC)
+	t3 := time.Date(2022, 3, 1, 0, 0, 0, 0, time.UTC)
+	commit(t1, map[string]string{"docs/a.md": "a1", "docs/b.md": "b1", "README.md": "readme"})
+	commit(t2, map[string]string{"docs/a.md": "a2", "docs/c.md": "c1"})
+	commit(t3, map[string]string{"docs/a.md": "a3", "docs/c.md": ""})
+
+	dates, err := FileDates(osfs.New(filepath.Join(dir, "docs")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	equal := func(want, got FileDate) {
+		assert.True(t, want.Created.Equal(got.Created), "created: %v, %v", want.Created, got.Created)
+		assert.True(t, want.Updated.Equal(got.Updated), "updated: %v, %v", want.Updated, got.Updated)
+	}
+	assert.Len(t, dates, 3)
+	equal(FileDate{Created: t1, Updated: t3}, dates["a.md"])
+	equal(FileDate{Created: t1, Updated: t1}, dates["b.md"])
+	equal(FileDate{Created: t2, Updated: t2}, dates["c.md"])
+
+	dates, err = FileDates(osfs.New(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	equal(FileDate{Created: t1, Updated: t1}, dates["README.md"])
+	equal(FileDate{Created: t1, Updated: t3}, dates["docs/a.md"])
+
+	_, err = FileDates(memfs.New())
+	assert.ErrorIs(t, err, git.ErrRepositoryNotExists)
+}
@@ -415,3 +415,109 @@ func (g *Git) Push(remote string, branch string, commitMsg string, force bool) e
 
 	return nil
 }
+
+// Head 返回当前工作区的提交 SHA
+func (g *Git) Head() (string, error) {
+	h, err := g.r.Head()
+	if err != nil {
+		return "", err
+	}
+	return h.Hash().String(), nil
+}
+
+// Checkout 将工作区切换到 ref 对应的提交并返回提交 SHA，ref 可以是分支、标签或者提交 SHA（支持缩写）。
+// 本地已经存在 ref 对应的提交 SHA 时不会访问远端仓库，否则会先拉取远端仓库的所有分支与标签。
+func (g *Git) Checkout(remote string, ref string) (string, error) {
+	wt, err := g.r.Worktree()
+	if err != nil {
+		return "", fmt.Errorf("worktree error: %w", err)
+	}
+
+	hash, err := g.resolveHash(ref)
+	if err != nil {
+		hash, err = g.fetchRef(remote, ref)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	g.log.Infof("git checkout %v (%v)", ref, hash)
+	err = wt.Checkout(&git.CheckoutOptions{
+		Hash:  hash,
+		Force: true,
+	})
+	if err != nil {
+		return "", fmt.Errorf("checkout %v error: %w", hash, err)
+	}
+	return hash.String(), nil
+}
+
+// resolveHash 只在本地查找 ref 为提交 SHA 的情况，分支与标签可能已经过时，需要从远端获取
+func (g *Git) resolveHash(ref string) (plumbing.Hash, error) {
+	if len(ref) < 4 || !isHex(ref) {
+		return plumbing.ZeroHash, plumbing.ErrReferenceNotFound
+	}
+	h, err := g.r.ResolveRevision(plumbing.Revision(ref))
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+	return *h, nil
+}
+
+func isHex(s string) bool {
+	for _, c := range s {
+		if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F') {
+			return false
+		}
+	}
+	return true
+}
+
+// fetchRef 拉取远端仓库的所有分支与标签，返回 ref 对应的提交
+func (g *Git) fetchRef(remote string, ref string) (plumbing.Hash, error) {
+	remoteName := "origin-temp"
+	err := g.r.DeleteRemote(remoteName)
+	if err != nil && err != git.ErrRemoteNotFound {
+		return plumbing.ZeroHash, err
+	}
+	_, err = g.r.CreateRemote(&config.RemoteConfig{
+		Name: remoteName,
+		URLs: []string{remote},
+	})
+	if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("CreateRemote error: %w", err)
+	}
+	defer func() {
+		err := g.r.DeleteRemote(remoteName)
+		if err != nil && err != git.ErrRemoteNotFound {
+			g.log.Errorf("DeleteReomte error: %v", err)
+		}
+	}()
+
+	g.log.Infof("git fetch %v", remote)
+	err = g.r.Fetch(&git.FetchOptions{
+		RemoteName: remoteName,
+		RefSpecs: []config.RefSpec{
+			config.RefSpec(fmt.Sprintf("+refs/heads/*:refs/remotes/%v/*", remoteName)),
+			"+refs/tags/*:refs/tags/*",
+		},
+		Auth: g.auth,
+		Progress: &logWrite{
+			log: g.log,
+		},
+		Tags:  git.NoTags,
+		Force: true,
+	})
+	if err != nil && err != git.NoErrAlreadyUpToDate {
+		return plumbing.ZeroHash, fmt.Errorf("fetch error: %w", err)
+	}
+
+	// 优先使用远端分支，本地分支可能已经过时
+	for _, rev := range []string{fmt.Sprintf("refs/remotes/%v/%v", remoteName, ref), ref} {
+		h, err := g.r.ResolveRevision(plumbing.Revision(rev))
+		if err == nil {
+			return *h, nil
+		}
+	}
+	return plumbing.ZeroHash, fmt.Errorf("unknown revision '%v' of %v", ref, remote)
+}
@@ -0,0 +1,122 @@
+package image
+
+import (
+	"errors"
+	"fmt"
+	"github.com/HugoSmits86/nativewebp"
+	"github.com/nfnt/resize"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"math"
+)
+
+// ErrUnsupportedFormat 表示不支持编码为该格式，如 gif
+var ErrUnsupportedFormat = errors.New("unsupported image format")
+
+// Decode 解码 jpeg 或 png 图片，返回图片与格式名称
+func Decode(r io.Reader) (image.Image, string, error) {
+	return image.Decode(r)
+}
+
+// DecodeConfig 只读取图片的尺寸与格式，不解码整张图片
+func DecodeConfig(r io.Reader) (image.Config, string, error) {
+	return image.DecodeConfig(r)
+}
+
+const (
+	FitCover   = "cover"   // 等比缩放至覆盖目标尺寸，再居中裁剪
+	FitContain = "contain" // 等比缩放至目标尺寸以内，不裁剪
+	FitFill    = "fill"    // 拉伸至目标尺寸
+)
+
+// TransformOption 描述图片的缩放与裁剪，Width 与 Height 只设置一个时等比缩放，都为 0 时保持原图
+type TransformOption struct {
+	Width  int
+	Height int
+	Fit    string // 同时设置了宽高时生效，默认为 cover
+}
+
+// Size 返回原图为 sw x sh 时，缩放后的尺寸（rw, rh）与裁剪后输出的尺寸（ow, oh），不会放大图片
+func (o TransformOption) Size(sw, sh int) (rw, rh, ow, oh int) {
+	w, h := o.Width, o.Height
+	switch {
+	case w <= 0 && h <= 0:
+		return sw, sh, sw, sh
+	case h <= 0:
+		w = minInt(w, sw)
+		h = scale(sh, float64(w)/float64(sw))
+		return w, h, w, h
+	case w <= 0:
+		h = minInt(h, sh)
+		w = scale(sw, float64(h)/float64(sh))
+		return w, h, w, h
+	}
+
+	switch o.Fit {
+	case FitContain:
+		s := math.Min(math.Min(float64(w)/float64(sw), float64(h)/float64(sh)), 1)
+		rw, rh = scale(sw, s), scale(sh, s)
+		return rw, rh, rw, rh
+	case FitFill:
+		w, h = minInt(w, sw), minInt(h, sh)
+		return w, h, w, h
+	}
+	s := math.Max(float64(w)/float64(sw), float64(h)/float64(sh))
+	if s > 1 {
+		// 原图不够大时，缩小目标尺寸并保持其比例
+		w, h = scale(w, 1/s), scale(h, 1/s)
+		s = 1
+	}
+	rw, rh = scale(sw, s), scale(sh, s)
+	return rw, rh, minInt(w, rw), minInt(h, rh)
+}
+
+func scale(n int, s float64) int {
+	r := int(math.Round(float64(n) * s))
+	if r < 1 {
+		return 1
+	}
+	return r
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// Transform 按照 TransformOption 缩放与裁剪图片
+func Transform(img image.Image, o TransformOption) image.Image {
+	b := img.Bounds()
+	rw, rh, ow, oh := o.Size(b.Dx(), b.Dy())
+	if rw != b.Dx() || rh != b.Dy() {
+		img = resize.Resize(uint(rw), uint(rh), img, resize.Lanczos3)
+	}
+	if ow == rw && oh == rh {
+		return img
+	}
+
+	b = img.Bounds()
+	x := b.Min.X + (rw-ow)/2
+	y := b.Min.Y + (rh-oh)/2
+	dst := image.NewRGBA(image.Rect(0, 0, ow, oh))
+	draw.Draw(dst, dst.Bounds(), img, image.Pt(x, y), draw.Src)
+	return dst
+}
+
+// Encode 将图片编码为 format 格式（jpeg、png 或 webp），quality 为 1-100，只对 jpeg 生效，webp 使用无损编码
+func Encode(w io.Writer, img image.Image, format string, quality int) error {
+	switch format {
+	case "jpeg", "jpg":
+		return jpeg.Encode(w, img, &jpeg.Options{Quality: quality})
+	case "png":
+		return png.Encode(w, img)
+	case "webp":
+		return nativewebp.Encode(w, img, nil)
+	}
+	return fmt.Errorf("%w: %v", ErrUnsupportedFormat, format)
+}
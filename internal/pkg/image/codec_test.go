@@ -0,0 +1,54 @@
+package image
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"testing"
+)
+
+func TestTransformSize(t *testing.T) {
+	cases := []struct {
+		opt            TransformOption
+		rw, rh, ow, oh int
+	}{
+		{TransformOption{}, 400, 200, 400, 200},
+		{TransformOption{Width: 100}, 100, 50, 100, 50},
+		{TransformOption{Width: 800}, 400, 200, 400, 200},
+		{TransformOption{Height: 50}, 100, 50, 100, 50},
+		{TransformOption{Width: 100, Height: 100}, 200, 100, 100, 100},
+		{TransformOption{Width: 100, Height: 100, Fit: FitContain}, 100, 50, 100, 50},
+		{TransformOption{Width: 100, Height: 100, Fit: FitFill}, 100, 100, 100, 100},
+		// 原图不够大时不会放大，而是保持目标比例裁剪
+		{TransformOption{Width: 800, Height: 400}, 400, 200, 400, 200},
+		{TransformOption{Width: 600, Height: 600}, 400, 200, 200, 200},
+	}
+	for _, c := range cases {
+		rw, rh, ow, oh := c.opt.Size(400, 200)
+		if rw != c.rw || rh != c.rh || ow != c.ow || oh != c.oh {
+			t.Errorf("%+v: got %v %v %v %v, want %v %v %v %v", c.opt, rw, rh, ow, oh, c.rw, c.rh, c.ow, c.oh)
+		}
+	}
+
+	img := Transform(image.NewRGBA(image.Rect(0, 0, 400, 200)), TransformOption{Width: 100, Height: 100})
+	if b := img.Bounds(); b.Dx() != 100 || b.Dy() != 100 {
+		t.Errorf("transform: got %v", b)
+	}
+}
+
+func TestEncodeWebp(t *testing.T) {
+	var buf bytes.Buffer
+	err := Encode(&buf, image.NewRGBA(image.Rect(0, 0, 40, 20)), "webp", 80)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bs := buf.Bytes()
+	if len(bs) < 30 || string(bs[:4]) != "RIFF" || string(bs[8:16]) != "WEBPVP8L" {
+		t.Fatalf("not a lossless webp: %q", bs)
+	}
+	// VP8L 头部中保存了宽高减一，各占 14 位
+	bits := binary.LittleEndian.Uint32(bs[21:25])
+	if w, h := bits&0x3fff+1, (bits>>14)&0x3fff+1; w != 40 || h != 20 {
+		t.Errorf("size: got %vx%v", w, h)
+	}
+}
@@ -0,0 +1,644 @@
+// Package mathml 将 LaTeX 数学公式转换为 MathML，只支持常用的语法：
+// 上下标、分数、根式、希腊字母与常用符号、\left \right、重音、字体（\mathbb 等）、\text 以及 matrix、cases、aligned 等环境。
+package mathml
+
+import (
+	"fmt"
+	"html"
+	"strings"
+	"unicode"
+)
+
+// FromLatex 将 LaTeX 公式转换为 <math> 元素，display 为 true 时为块级公式。
+// 输出的结构与 KaTeX 的 mathml 模式一致，原始公式保存在 annotation 中。
+func FromLatex(tex string, display bool) (string, error) {
+	p := &parser{s: []rune(tex)}
+	p.display = display
+	list, err := p.parseRows()
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	sb.WriteString(`<math xmlns="http://www.w3.org/1998/Math/MathML"`)
+	if display {
+		sb.WriteString(` display="block"`)
+	}
+	sb.WriteString(`><semantics>`)
+	sb.WriteString(mrow(list))
+	sb.WriteString(`<annotation encoding="application/x-tex">`)
+	sb.WriteString(html.EscapeString(tex))
+	sb.WriteString(`</annotation></semantics></math>`)
+	return sb.String(), nil
+}
+
+type parser struct {
+	s       []rune
+	i       int
+	display bool
+	variant string // \mathbb 等字体命令中的 mathvariant
+}
+
+// atom 是一个可以添加上下标的元素
+type atom struct {
+	ml string
+	op bool // 大型运算符（如 \sum、\lim），块级公式中上下标位于正上方与正下方
+}
+
+func mrow(list []string) string {
+	if len(list) == 1 {
+		return list[0]
+	}
+	return "<mrow>" + strings.Join(list, "") + "</mrow>"
+}
+
+func el(name string, attr string, body string) string {
+	if attr != "" {
+		attr = " " + attr
+	}
+	return "<" + name + attr + ">" + body + "</" + name + ">"
+}
+
+func (p *parser) eof() bool {
+	return p.i >= len(p.s)
+}
+
+func (p *parser) peek() rune {
+	if p.eof() {
+		return 0
+	}
+	return p.s[p.i]
+}
+
+func (p *parser) skipSpace() {
+	for !p.eof() && unicode.IsSpace(p.s[p.i]) {
+		p.i++
+	}
+}
+
+// peekCommand 返回下一个命令的名称，下一个不是命令时返回空字符串，不会移动位置
+func (p *parser) peekCommand() string {
+	i := p.i
+	name := p.readCommand()
+	p.i = i
+	return name
+}
+
+// readCommand 读取 \ 开头的命令名称，单个非字母字符也是命令，如 \, \{
+func (p *parser) readCommand() string {
+	if p.peek() != '\\' {
+		return ""
+	}
+	p.i++
+	start := p.i
+	for !p.eof() && unicode.IsLetter(p.s[p.i]) {
+		p.i++
+	}
+	if p.i == start && !p.eof() {
+		p.i++
+	}
+	return string(p.s[start:p.i])
+}
+
+// atEnd 返回当前列表是否结束，列表以 }、&、\\、\right 或 \end 结束
+func (p *parser) atEnd() bool {
+	p.skipSpace()
+	if p.eof() || p.peek() == '}' || p.peek() == '&' {
+		return true
+	}
+	switch p.peekCommand() {
+	case `\`, "right", "end":
+		return true
+	}
+	return false
+}
+
+// parseRows 解析最外层的公式，\\ 作为换行
+func (p *parser) parseRows() ([]string, error) {
+	var list []string
+	for {
+		l, err := p.parseList()
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, l...)
+		if p.eof() {
+			return list, nil
+		}
+		switch {
+		case p.peek() == '}':
+			return nil, fmt.Errorf("unexpected '}' at %v", p.i)
+		case p.peek() == '&':
+			return nil, fmt.Errorf("unexpected '&' outside of an environment")
+		case p.peekCommand() == `\`:
+			p.readCommand()
+			list = append(list, `<mspace linebreak="newline"/>`)
+		default:
+			return nil, fmt.Errorf("unexpected '\\%v'", p.peekCommand())
+		}
+	}
+}
+
+// parseList 解析多个元素，直到列表结束
+func (p *parser) parseList() ([]string, error) {
+	var list []string
+	for !p.atEnd() {
+		a, err := p.parseAtom()
+		if err != nil {
+			return nil, err
+		}
+		a, err = p.parseScripts(a)
+		if err != nil {
+			return nil, err
+		}
+		if a.ml != "" {
+			list = append(list, a.ml)
+		}
+	}
+	return list, nil
+}
+
+// parseScripts 解析元素之后的 ^、_ 与 '
+func (p *parser) parseScripts(base atom) (atom, error) {
+	var sub string
+	var sup []string
+	for {
+		p.skipSpace()
+		switch p.peek() {
+		case '^', '_':
+			c := p.peek()
+			p.i++
+			arg, err := p.parseArg()
+			if err != nil {
+				return base, err
+			}
+			if c == '^' {
+				sup = append(sup, arg)
+			} else {
+				sub = arg
+			}
+			continue
+		case '\'':
+			p.i++
+			sup = append(sup, "<mo>′</mo>")
+			continue
+		}
+		break
+	}
+	if sub == "" && len(sup) == 0 {
+		return base, nil
+	}
+	if base.ml == "" {
+		base.ml = "<mrow></mrow>"
+	}
+
+	under := base.op && p.display
+	switch {
+	case sub != "" && len(sup) != 0:
+		if under {
+			return atom{ml: el("munderover", "", base.ml+sub+mrow(sup))}, nil
+		}
+		return atom{ml: el("msubsup", "", base.ml+sub+mrow(sup))}, nil
+	case sub != "":
+		if under {
+			return atom{ml: el("munder", "", base.ml+sub)}, nil
+		}
+		return atom{ml: el("msub", "", base.ml+sub)}, nil
+	default:
+		if under {
+			return atom{ml: el("mover", "", base.ml+mrow(sup))}, nil
+		}
+		return atom{ml: el("msup", "", base.ml+mrow(sup))}, nil
+	}
+}
+
+// parseArg 解析命令的参数：{} 中的内容，或者单个字符、命令
+func (p *parser) parseArg() (string, error) {
+	p.skipSpace()
+	if p.eof() {
+		return "", fmt.Errorf("missing argument")
+	}
+	if p.peek() == '{' {
+		return p.parseGroup()
+	}
+	if unicode.IsDigit(p.peek()) {
+		c := p.peek()
+		p.i++
+		return p.mn(string(c)), nil
+	}
+	a, err := p.parseAtom()
+	if err != nil {
+		return "", err
+	}
+	return a.ml, nil
+}
+
+func (p *parser) parseGroup() (string, error) {
+	p.i++ // {
+	list, err := p.parseList()
+	if err != nil {
+		return "", err
+	}
+	if p.peek() != '}' {
+		return "", fmt.Errorf("missing '}'")
+	}
+	p.i++
+	if len(list) == 0 {
+		return "<mrow></mrow>", nil
+	}
+	return mrow(list), nil
+}
+
+// readRaw 读取 {} 中的原始文本，用于 \text、\operatorname 与环境名称
+func (p *parser) readRaw() (string, error) {
+	p.skipSpace()
+	if p.peek() != '{' {
+		return "", fmt.Errorf("missing '{'")
+	}
+	p.i++
+	start := p.i
+	depth := 0
+	for !p.eof() {
+		switch p.s[p.i] {
+		case '\\':
+			p.i++
+		case '{':
+			depth++
+		case '}':
+			if depth == 0 {
+				s := string(p.s[start:p.i])
+				p.i++
+				return s, nil
+			}
+			depth--
+		}
+		p.i++
+	}
+	return "", fmt.Errorf("missing '}'")
+}
+
+func (p *parser) mi(s string) string {
+	if p.variant != "" {
+		return el("mi", `mathvariant="`+p.variant+`"`, html.EscapeString(s))
+	}
+	return el("mi", "", html.EscapeString(s))
+}
+
+func (p *parser) mn(s string) string {
+	if p.variant != "" && p.variant != "normal" {
+		return el("mn", `mathvariant="`+p.variant+`"`, s)
+	}
+	return el("mn", "", s)
+}
+
+func mo(s string) string {
+	return el("mo", "", html.EscapeString(s))
+}
+
+var charOperators = map[rune]string{
+	'-': "−",
+	'*': "∗",
+}
+
+func (p *parser) parseAtom() (atom, error) {
+	c := p.peek()
+	switch {
+	case c == '{':
+		ml, err := p.parseGroup()
+		return atom{ml: ml}, err
+	case c == '\\':
+		return p.parseCommand()
+	case c == '}':
+		return atom{}, fmt.Errorf("unexpected '}'")
+	case c == '^' || c == '_':
+		// 没有底数的上下标，如 {}^2
+		return atom{}, nil
+	case unicode.IsDigit(c) || (c == '.' && p.i+1 < len(p.s) && unicode.IsDigit(p.s[p.i+1])):
+		start := p.i
+		for !p.eof() && (unicode.IsDigit(p.s[p.i]) || p.s[p.i] == '.') {
+			p.i++
+		}
+		return atom{ml: p.mn(string(p.s[start:p.i]))}, nil
+	case c == '~':
+		p.i++
+		return atom{ml: `<mtext>&#160;</mtext>`}, nil
+	case unicode.IsLetter(c):
+		p.i++
+		return atom{ml: p.mi(string(c))}, nil
+	}
+	p.i++
+	if s, ok := charOperators[c]; ok {
+		return atom{ml: mo(s)}, nil
+	}
+	return atom{ml: mo(string(c))}, nil
+}
+
+func (p *parser) parseCommand() (atom, error) {
+	name := p.readCommand()
+	if name == "" {
+		return atom{}, fmt.Errorf("missing command name")
+	}
+
+	if s, ok := identifiers[name]; ok {
+		return atom{ml: p.mi(s)}, nil
+	}
+	if s, ok := upperGreek[name]; ok {
+		return atom{ml: el("mi", `mathvariant="normal"`, s)}, nil
+	}
+	if s, ok := operators[name]; ok {
+		return atom{ml: mo(s)}, nil
+	}
+	if s, ok := largeOperators[name]; ok {
+		return atom{ml: el("mo", `movablelimits="false"`, s), op: !strings.Contains(name, "int")}, nil
+	}
+	if functions[name] {
+		return atom{ml: el("mi", "", name)}, nil
+	}
+	if limitFunctions[name] {
+		return atom{ml: el("mi", "", name), op: true}, nil
+	}
+	if w, ok := spaces[name]; ok {
+		return atom{ml: `<mspace width="` + w + `"/>`}, nil
+	}
+	if s, ok := accents[name]; ok {
+		arg, err := p.parseArg()
+		if err != nil {
+			return atom{}, err
+		}
+		return atom{ml: el("mover", `accent="true"`, arg+el("mo", `stretchy="`+s[1]+`"`, s[0]))}, nil
+	}
+	if v, ok := variants[name]; ok {
+		old := p.variant
+		p.variant = v
+		arg, err := p.parseArg()
+		p.variant = old
+		return atom{ml: arg}, err
+	}
+	if _, ok := bigSizes[name]; ok {
+		return p.parseBig(name)
+	}
+
+	switch name {
+	case "frac", "dfrac", "tfrac", "cfrac":
+		num, err := p.parseArg()
+		if err != nil {
+			return atom{}, err
+		}
+		den, err := p.parseArg()
+		if err != nil {
+			return atom{}, err
+		}
+		return atom{ml: el("mfrac", "", num+den)}, nil
+	case "binom":
+		n, err := p.parseArg()
+		if err != nil {
+			return atom{}, err
+		}
+		k, err := p.parseArg()
+		if err != nil {
+			return atom{}, err
+		}
+		return atom{ml: "<mrow><mo>(</mo>" + el("mfrac", `linethickness="0"`, n+k) + "<mo>)</mo></mrow>"}, nil
+	case "sqrt":
+		p.skipSpace()
+		var index string
+		if p.peek() == '[' {
+			p.i++
+			start := p.i
+			for !p.eof() && p.s[p.i] != ']' {
+				p.i++
+			}
+			if p.eof() {
+				return atom{}, fmt.Errorf("missing ']'")
+			}
+			sub := &parser{s: p.s[start:p.i]}
+			p.i++
+			list, err := sub.parseRows()
+			if err != nil {
+				return atom{}, err
+			}
+			index = mrow(list)
+		}
+		arg, err := p.parseArg()
+		if err != nil {
+			return atom{}, err
+		}
+		if index != "" {
+			return atom{ml: el("mroot", "", arg+index)}, nil
+		}
+		return atom{ml: el("msqrt", "", arg)}, nil
+	case "text", "textrm", "textnormal", "mbox", "textit", "textbf":
+		s, err := p.readRaw()
+		if err != nil {
+			return atom{}, err
+		}
+		s = strings.NewReplacer(`\{`, "{", `\}`, "}", `\ `, " ", `\%`, "%", `\$`, "$", `\&`, "&", `\_`, "_").Replace(s)
+		s = html.EscapeString(s)
+		switch name {
+		case "textit":
+			return atom{ml: el("mtext", `mathvariant="italic"`, s)}, nil
+		case "textbf":
+			return atom{ml: el("mtext", `mathvariant="bold"`, s)}, nil
+		}
+		return atom{ml: el("mtext", "", s)}, nil
+	case "operatorname":
+		s, err := p.readRaw()
+		if err != nil {
+			return atom{}, err
+		}
+		return atom{ml: el("mi", "", html.EscapeString(s))}, nil
+	case "underline":
+		arg, err := p.parseArg()
+		if err != nil {
+			return atom{}, err
+		}
+		return atom{ml: el("munder", `accentunder="true"`, arg+`<mo stretchy="true">_</mo>`)}, nil
+	case "left":
+		return p.parseLeftRight()
+	case "middle":
+		d, err := p.readDelimiter()
+		if err != nil {
+			return atom{}, err
+		}
+		return atom{ml: el("mo", `fence="true"`, html.EscapeString(d))}, nil
+	case "begin":
+		return p.parseEnvironment()
+	case "displaystyle", "textstyle", "limits", "nolimits":
+		return atom{}, nil
+	}
+	return atom{}, fmt.Errorf("unsupported command '\\%v'", name)
+}
+
+// readDelimiter 读取 \left、\right 与 \big 之后的分隔符，. 表示没有分隔符
+func (p *parser) readDelimiter() (string, error) {
+	p.skipSpace()
+	if p.eof() {
+		return "", fmt.Errorf("missing delimiter")
+	}
+	if p.peek() == '\\' {
+		name := p.readCommand()
+		if s, ok := delimiters[name]; ok {
+			return s, nil
+		}
+		return "", fmt.Errorf("unsupported delimiter '\\%v'", name)
+	}
+	c := p.peek()
+	p.i++
+	switch c {
+	case '.':
+		return "", nil
+	case '(', ')', '[', ']', '|', '/', '<', '>':
+		return string(c), nil
+	}
+	return "", fmt.Errorf("unsupported delimiter '%c'", c)
+}
+
+func (p *parser) parseLeftRight() (atom, error) {
+	left, err := p.readDelimiter()
+	if err != nil {
+		return atom{}, err
+	}
+	list, err := p.parseList()
+	if err != nil {
+		return atom{}, err
+	}
+	if p.readCommand() != "right" {
+		return atom{}, fmt.Errorf("missing '\\right'")
+	}
+	right, err := p.readDelimiter()
+	if err != nil {
+		return atom{}, err
+	}
+
+	var sb strings.Builder
+	sb.WriteString("<mrow>")
+	if left != "" {
+		sb.WriteString(el("mo", `fence="true"`, html.EscapeString(left)))
+	}
+	sb.WriteString(strings.Join(list, ""))
+	if right != "" {
+		sb.WriteString(el("mo", `fence="true"`, html.EscapeString(right)))
+	}
+	sb.WriteString("</mrow>")
+	return atom{ml: sb.String()}, nil
+}
+
+var bigSizes = map[string]string{
+	"big": "1.2em", "bigl": "1.2em", "bigr": "1.2em", "bigm": "1.2em",
+	"Big": "1.623em", "Bigl": "1.623em", "Bigr": "1.623em", "Bigm": "1.623em",
+	"bigg": "2.047em", "biggl": "2.047em", "biggr": "2.047em", "biggm": "2.047em",
+	"Bigg": "2.470em", "Biggl": "2.470em", "Biggr": "2.470em", "Biggm": "2.470em",
+}
+
+func (p *parser) parseBig(name string) (atom, error) {
+	d, err := p.readDelimiter()
+	if err != nil {
+		return atom{}, err
+	}
+	size := bigSizes[name]
+	return atom{ml: el("mo", `fence="false" stretchy="true" minsize="`+size+`" maxsize="`+size+`"`, html.EscapeString(d))}, nil
+}
+
+// environments 是支持的环境，值为左右两侧的分隔符
+var environments = map[string][2]string{
+	"matrix":   {"", ""},
+	"pmatrix":  {"(", ")"},
+	"bmatrix":  {"[", "]"},
+	"Bmatrix":  {"{", "}"},
+	"vmatrix":  {"|", "|"},
+	"Vmatrix":  {"‖", "‖"},
+	"cases":    {"{", ""},
+	"array":    {"", ""},
+	"aligned":  {"", ""},
+	"align":    {"", ""},
+	"align*":   {"", ""},
+	"gathered": {"", ""},
+	"split":    {"", ""},
+}
+
+func (p *parser) parseEnvironment() (atom, error) {
+	name, err := p.readRaw()
+	if err != nil {
+		return atom{}, err
+	}
+	fences, ok := environments[name]
+	if !ok {
+		return atom{}, fmt.Errorf("unsupported environment '%v'", name)
+	}
+	if name == "array" {
+		// 忽略列的格式，如 {cc}
+		if _, err = p.readRaw(); err != nil {
+			return atom{}, err
+		}
+	}
+
+	var rows [][]string
+	var row []string
+	for {
+		list, err := p.parseList()
+		if err != nil {
+			return atom{}, err
+		}
+		row = append(row, mrow(list))
+
+		if p.eof() {
+			return atom{}, fmt.Errorf("missing '\\end{%v}'", name)
+		}
+		if p.peek() == '&' {
+			p.i++
+			continue
+		}
+		if p.peek() == '}' {
+			return atom{}, fmt.Errorf("unexpected '}'")
+		}
+		cmd := p.readCommand()
+		rows = append(rows, row)
+		row = nil
+		if cmd == "end" {
+			end, err := p.readRaw()
+			if err != nil {
+				return atom{}, err
+			}
+			if end != name {
+				return atom{}, fmt.Errorf("'\\begin{%v}' ended by '\\end{%v}'", name, end)
+			}
+			break
+		}
+		if cmd != `\` {
+			return atom{}, fmt.Errorf("unexpected '\\%v'", cmd)
+		}
+	}
+	// 忽略最后一行末尾的 \\
+	if len(rows) > 1 && len(rows[len(rows)-1]) == 1 && rows[len(rows)-1][0] == "<mrow></mrow>" {
+		rows = rows[:len(rows)-1]
+	}
+
+	var attr string
+	switch name {
+	case "cases":
+		attr = `columnalign="left"`
+	case "aligned", "align", "align*", "split":
+		attr = `columnalign="right left" columnspacing="0"`
+	}
+	var sb strings.Builder
+	for _, r := range rows {
+		sb.WriteString("<mtr>")
+		for _, c := range r {
+			sb.WriteString(el("mtd", "", c))
+		}
+		sb.WriteString("</mtr>")
+	}
+	table := el("mtable", attr, sb.String())
+	if fences[0] == "" && fences[1] == "" {
+		return atom{ml: table}, nil
+	}
+	ml := "<mrow>"
+	if fences[0] != "" {
+		ml += el("mo", `fence="true"`, fences[0])
+	}
+	ml += table
+	if fences[1] != "" {
+		ml += el("mo", `fence="true"`, fences[1])
+	}
+	return atom{ml: ml + "</mrow>"}, nil
+}
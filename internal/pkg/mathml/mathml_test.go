@@ -0,0 +1,34 @@
+package mathml
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestFromLatex(t *testing.T) {
+	cases := []struct {
+		tex     string
+		display bool
+		want    string
+	}{
+		{tex: `x^2`, want: `<msup><mi>x</mi><mn>2</mn></msup>`},
+		{tex: `\frac{1}{2}`, want: `<mfrac><mn>1</mn><mn>2</mn></mfrac>`},
+		{tex: `\sqrt[3]{x}`, want: `<mroot><mi>x</mi><mn>3</mn></mroot>`},
+		{tex: `\left( \frac{1}{2} \right)`, want: `<mrow><mo fence="true">(</mo><mfrac><mn>1</mn><mn>2</mn></mfrac><mo fence="true">)</mo></mrow>`},
+		// 块级公式中大型运算符的上下标位于正上方与正下方
+		{tex: `\sum_{i=1}^n i`, display: true, want: `<mrow><munderover><mo movablelimits="false">∑</mo><mrow><mi>i</mi><mo>=</mo><mn>1</mn></mrow><mi>n</mi></munderover><mi>i</mi></mrow>`},
+		{tex: `\begin{cases} 1 & x>0 \\ 0 & x \le 0 \end{cases}`, want: `<mrow><mo fence="true">{</mo><mtable columnalign="left"><mtr><mtd><mn>1</mn></mtd><mtd><mrow><mi>x</mi><mo>&gt;</mo><mn>0</mn></mrow></mtd></mtr><mtr><mtd><mn>0</mn></mtd><mtd><mrow><mi>x</mi><mo>≤</mo><mn>0</mn></mrow></mtd></mtr></mtable></mrow>`},
+	}
+	for _, c := range cases {
+		s, err := FromLatex(c.tex, c.display)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Contains(t, s, "<semantics>"+c.want+"<annotation", c.tex)
+	}
+
+	_, err := FromLatex(`\foo`, false)
+	assert.EqualError(t, err, `unsupported command '\foo'`)
+	_, err = FromLatex(`{a`, false)
+	assert.Error(t, err)
+}
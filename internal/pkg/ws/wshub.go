@@ -5,6 +5,7 @@ import (
 	"github.com/gorilla/websocket"
 	"io"
 	"math/rand"
+	"strings"
 	"sync"
 	"time"
 )
@@ -94,6 +95,15 @@ func (h *WsHub) Close(key string) {
 	return
 }
 
+// Remove 移除 key 对应的连接，只有当前连接仍是 conn 时才会移除，避免移除同一个 key 重新建立的连接
+func (h *WsHub) Remove(key string, conn *websocket.Conn) {
+	h.l.Lock()
+	defer h.l.Unlock()
+	if o, ok := h.conns[key]; ok && o == conn {
+		delete(h.conns, key)
+	}
+}
+
 func (h *WsHub) SendAll(body []byte) error {
 	for _, o := range h.conns {
 		err := o.WriteMessage(1, body)
@@ -104,3 +114,21 @@ func (h *WsHub) SendAll(body []byte) error {
 
 	return nil
 }
+
+// Broadcast 发送消息给所有 key 以 prefix 开头的连接，消息不会被缓存，发送失败的连接会被关闭
+func (h *WsHub) Broadcast(prefix string, body []byte) {
+	h.l.Lock()
+	defer h.l.Unlock()
+
+	for key, o := range h.conns {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		err := o.WriteMessage(1, body)
+		if err != nil {
+			_ = o.Close()
+			delete(h.conns, key)
+			delete(h.msg, key)
+		}
+	}
+}
@@ -24,6 +24,7 @@ func init() {
 	rootCmd.AddCommand(cmd.Api())
 	rootCmd.AddCommand(cmd.Server())
 	rootCmd.AddCommand(cmd.Build())
+	rootCmd.AddCommand(cmd.Theme())
 	rootCmd.AddCommand(cmd.Version("v0.3.3"))
 }
 